@@ -0,0 +1,174 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	sha256SumFile = "sha256sum.txt"
+	sha512SumFile = "sha512sum.txt"
+)
+
+// hashArtifacts populates the downloads of r from its artifacts directory
+// and writes the checksum files, which are only written outside of dry run
+// mode.
+func hashArtifacts(r *release, dry bool) error {
+	var err error
+
+	if r.Downloads, err = collectDownloads(r.Artifacts, r.ArtifactsSHA512); err != nil {
+		return fmt.Errorf("failed to hash artifacts: %w", err)
+	}
+
+	logrus.Infof("hashed %d artifacts in %s", len(r.Downloads), r.Artifacts)
+
+	if dry {
+		return nil
+	}
+
+	if err = writeChecksums(r.Artifacts, r.Downloads, r.ArtifactsSHA512); err != nil {
+		return fmt.Errorf("failed to write checksums: %w", err)
+	}
+
+	return nil
+}
+
+// collectDownloads hashes every regular file under dir, checksum files
+// like the ones written by writeChecksums are skipped in every directory.
+func collectDownloads(dir string, withSHA512 bool) ([]download, error) {
+	var downloads []download
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		name = filepath.ToSlash(name)
+		if isChecksumFile(name) {
+			return nil
+		}
+
+		dl, err := hashFile(path, withSHA512)
+		if err != nil {
+			return fmt.Errorf("failed to hash %s: %w", name, err)
+		}
+
+		dl.Filename = name
+		downloads = append(downloads, dl)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(downloads, func(i, j int) bool {
+		return downloads[i].Filename < downloads[j].Filename
+	})
+
+	return downloads, nil
+}
+
+func isChecksumFile(name string) bool {
+	base := filepath.Base(name)
+
+	return base == sha256SumFile || base == sha512SumFile
+}
+
+func hashFile(path string, withSHA512 bool) (download, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return download{}, err
+	}
+
+	defer f.Close() //nolint: errcheck
+
+	var (
+		h256 = sha256.New()
+		h512 hash.Hash
+		w    io.Writer = h256
+	)
+
+	if withSHA512 {
+		h512 = sha512.New()
+		w = io.MultiWriter(h256, h512)
+	}
+
+	size, err := io.Copy(w, f)
+	if err != nil {
+		return download{}, err
+	}
+
+	dl := download{
+		Hash: hex.EncodeToString(h256.Sum(nil)),
+		Size: size,
+	}
+
+	if h512 != nil {
+		dl.SHA512 = hex.EncodeToString(h512.Sum(nil))
+	}
+
+	return dl, nil
+}
+
+// writeChecksums writes sha256sum(1) compatible checksum files into dir, a
+// stale SHA-512 checksum file is removed when SHA-512 is disabled.
+func writeChecksums(dir string, downloads []download, withSHA512 bool) error {
+	var sum256, sum512 strings.Builder
+
+	for _, dl := range downloads {
+		fmt.Fprintf(&sum256, "%s  %s\n", dl.Hash, dl.Filename)
+		fmt.Fprintf(&sum512, "%s  %s\n", dl.SHA512, dl.Filename)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, sha256SumFile), []byte(sum256.String()), 0o644); err != nil {
+		return err
+	}
+
+	if !withSHA512 {
+		if err := os.Remove(filepath.Join(dir, sha512SumFile)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		return nil
+	}
+
+	return os.WriteFile(filepath.Join(dir, sha512SumFile), []byte(sum512.String()), 0o644)
+}
@@ -0,0 +1,127 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCollectDownloads(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"tool-linux-amd64":        "hello\n",
+		"nested/tool-darwin-arm":  "",
+		"nested/" + sha256SumFile: "nested",
+		sha256SumFile:             "stale",
+		sha512SumFile:             "stale",
+	} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	downloads, err := collectDownloads(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []download{
+		{
+			Filename: "nested/tool-darwin-arm",
+			Hash:     "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			SHA512:   "cf83e1357eefb8bdf1542850d66d8007d620e4050b5715dc83f4a921d36ce9ce47d0d13c5d85f2b0ff8318d2877eec2f63b931bd47417a81a538327af927da3e",
+			Size:     0,
+		},
+		{
+			Filename: "tool-linux-amd64",
+			Hash:     "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+			SHA512:   "e7c22b994c59d9cf2b48e549b1e24666636045930d3da7c1acb299d1c3b7f931f94aae41edda2c2b207a36e10f8bcb8d45223e54878f5b316e7ce3b6bc019629",
+			Size:     6,
+		},
+	}
+
+	if len(downloads) != len(expected) {
+		t.Fatalf("unexpected downloads %+v", downloads)
+	}
+
+	for i := range expected {
+		if downloads[i] != expected[i] {
+			t.Errorf("[%d] unexpected download %+v, expected %+v", i, downloads[i], expected[i])
+		}
+	}
+
+	if err = writeChecksums(dir, downloads, false); err != nil {
+		t.Fatal(err)
+	}
+
+	sums, err := os.ReadFile(filepath.Join(dir, sha256SumFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(sums) != expected[0].Hash+"  nested/tool-darwin-arm\n"+expected[1].Hash+"  tool-linux-amd64\n" {
+		t.Errorf("unexpected %s contents %q", sha256SumFile, sums)
+	}
+
+	if _, err = os.Stat(filepath.Join(dir, sha512SumFile)); !os.IsNotExist(err) {
+		t.Errorf("expected stale %s to be removed without sha512", sha512SumFile)
+	}
+}
+
+func TestHashArtifactsDryRun(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "tool"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &release{Artifacts: dir, ArtifactsSHA512: true}
+
+	if err := hashArtifacts(r, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(r.Downloads) != 1 || r.Downloads[0].Filename != "tool" {
+		t.Fatalf("unexpected downloads %+v", r.Downloads)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("expected no checksum files in dry run mode, got %d files", len(entries))
+	}
+
+	if err = hashArtifacts(r, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{sha256SumFile, sha512SumFile} {
+		if _, err = os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s to be written: %v", name, err)
+		}
+	}
+}
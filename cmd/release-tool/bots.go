@@ -0,0 +1,140 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Modes for the commits of bots in the changelog.
+const (
+	botCommitsShow     = "show"
+	botCommitsHide     = "hide"
+	botCommitsCollapse = "collapse"
+)
+
+// defaultBotAuthors matches the GitHub app accounts, such as
+// `dependabot[bot]` and `renovate[bot]`.
+var defaultBotAuthors = []string{`\[bot\]`}
+
+type botOptions struct {
+	// Authors are patterns matching the name or email of bots, defaults
+	// to the `[bot]` accounts.
+	Authors []string `toml:"authors" json:"authors" yaml:"authors"`
+	// Subjects are patterns matching the subject of commits made on
+	// behalf of bots.
+	Subjects []string `toml:"subjects" json:"subjects" yaml:"subjects"`
+	// Commits is whether the commits of bots are shown, hidden or
+	// collapsed into a count in the changelog.
+	Commits string `toml:"commits" json:"commits" yaml:"commits"`
+}
+
+// botFilter excludes bots from the contributors and their commits from
+// the changelog.
+type botFilter struct {
+	authors  []*regexp.Regexp
+	subjects []*regexp.Regexp
+	commits  string
+}
+
+func newBotFilter(opts botOptions) (*botFilter, error) {
+	authors := opts.Authors
+	if authors == nil {
+		authors = defaultBotAuthors
+	}
+
+	f := &botFilter{
+		commits: opts.Commits,
+	}
+
+	switch f.commits {
+	case "":
+		f.commits = botCommitsShow
+	case botCommitsShow, botCommitsHide, botCommitsCollapse:
+	default:
+		return nil, fmt.Errorf("invalid bot commits %q, must be %s, %s or %s", opts.Commits, botCommitsShow, botCommitsHide, botCommitsCollapse)
+	}
+
+	for _, p := range authors {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bot author pattern: %w", err)
+		}
+
+		f.authors = append(f.authors, re)
+	}
+
+	for _, p := range opts.Subjects {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bot subject pattern: %w", err)
+		}
+
+		f.subjects = append(f.subjects, re)
+	}
+
+	return f, nil
+}
+
+// isBot reports whether the author is a bot.
+func (f *botFilter) isBot(name, email string) bool {
+	for _, re := range f.authors {
+		if re.MatchString(name) || re.MatchString(email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isBotChange reports whether the change was made by or on behalf of a bot.
+func (f *botFilter) isBotChange(c change) bool {
+	if f.isBot(c.authorName, c.authorEmail) {
+		return true
+	}
+
+	for _, re := range f.subjects {
+		if re.MatchString(c.Description) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterChanges drops the changes of bots unless they are shown, the
+// number of collapsed changes is returned.
+func (f *botFilter) filterChanges(changes []change) ([]change, int) {
+	if f.commits == botCommitsShow {
+		return changes, 0
+	}
+
+	filtered := make([]change, 0, len(changes))
+
+	for _, c := range changes {
+		if !f.isBotChange(c) {
+			filtered = append(filtered, c)
+		}
+	}
+
+	if f.commits == botCommitsCollapse {
+		return filtered, len(changes) - len(filtered)
+	}
+
+	return filtered, 0
+}
@@ -0,0 +1,111 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestBotFilter(t *testing.T) {
+	changes := []change{
+		{Commit: "1", Description: "feat: add bots", authorName: "Jane", authorEmail: "jane@example.com"},
+		{Commit: "2", Description: "build(deps): bump x", authorName: "dependabot[bot]", authorEmail: "49699333+dependabot[bot]@users.noreply.github.com"},
+		{Commit: "3", Description: "chore(deps): update y", authorName: "Renovate Bot", authorEmail: "bot@renovateapp.com"},
+		{Commit: "4", Description: "fix: bots", authorName: "John", authorEmail: "john@example.com"},
+	}
+
+	for _, tc := range []struct {
+		name      string
+		config    string
+		contrib   []string
+		commits   string
+		collapsed int
+	}{
+		{
+			name:    "Default",
+			contrib: []string{"Jane", "Renovate Bot", "John"},
+			commits: "1234",
+		},
+		{
+			name:      "Collapse",
+			config:    "commits = \"collapse\"",
+			contrib:   []string{"Jane", "Renovate Bot", "John"},
+			commits:   "134",
+			collapsed: 1,
+		},
+		{
+			name:    "HideSubjects",
+			config:  "subjects = ['^chore\\(deps\\):']\ncommits = \"hide\"",
+			contrib: []string{"Jane", "Renovate Bot", "John"},
+			commits: "14",
+		},
+		{
+			name:    "Authors",
+			config:  "authors = ['\\[bot\\]', '^bot@renovateapp\\.com$']\ncommits = \"hide\"",
+			contrib: []string{"Jane", "John"},
+			commits: "14",
+		},
+		{
+			name:    "NoAuthors",
+			config:  "authors = []\ncommits = \"hide\"",
+			contrib: []string{"Jane", "dependabot[bot]", "Renovate Bot", "John"},
+			commits: "1234",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var opts botOptions
+			if _, err := toml.Decode(tc.config, &opts); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := newBotFilter(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var contrib []string
+
+			for _, c := range changes {
+				if !f.isBot(c.authorName, c.authorEmail) {
+					contrib = append(contrib, c.authorName)
+				}
+			}
+
+			if !reflect.DeepEqual(contrib, tc.contrib) {
+				t.Errorf("unexpected contributors %v, expected %v", contrib, tc.contrib)
+			}
+
+			filtered, collapsed := f.filterChanges(changes)
+
+			var commits string
+			for _, c := range filtered {
+				commits += c.Commit
+			}
+
+			if commits != tc.commits || collapsed != tc.collapsed {
+				t.Errorf("unexpected commits %q with %d collapsed, expected %q with %d", commits, collapsed, tc.commits, tc.collapsed)
+			}
+		})
+	}
+
+	if _, err := newBotFilter(botOptions{Commits: "squash"}); err == nil {
+		t.Error("expected invalid commits mode to fail")
+	}
+}
@@ -0,0 +1,153 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// releaseConfigFiles are the locations of the GitHub generated release
+// notes configuration.
+var releaseConfigFiles = []string{".github/release.yml", ".github/release.yaml"}
+
+// releaseConfig is the GitHub generated release notes configuration.
+type releaseConfig struct {
+	Changelog struct {
+		Exclude    releaseExclude    `yaml:"exclude"`
+		Categories []releaseCategory `yaml:"categories"`
+	} `yaml:"changelog"`
+}
+
+type releaseExclude struct {
+	Labels  []string `yaml:"labels"`
+	Authors []string `yaml:"authors"`
+}
+
+type releaseCategory struct {
+	Title   string         `yaml:"title"`
+	Labels  []string       `yaml:"labels"`
+	Exclude releaseExclude `yaml:"exclude"`
+}
+
+// loadReleaseConfig reads the release notes configuration at rev, it
+// returns nil when the repository has none.
+func loadReleaseConfig(rev string) (*releaseConfig, error) {
+	for _, file := range releaseConfigFiles {
+		r, err := fileFromRev(rev, file)
+		if err != nil {
+			continue
+		}
+
+		var cfg releaseConfig
+
+		if err = yaml.NewDecoder(r).Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+
+		logrus.Debugf("categorizing changes with %s", file)
+
+		return &cfg, nil
+	}
+
+	return nil, nil //nolint: nilnil
+}
+
+// excludes reports whether the pull request is excluded, changes without
+// a pull request have no labels or author.
+func (e releaseExclude) excludes(pr *pullRequest) bool {
+	if pr == nil {
+		return false
+	}
+
+	for _, author := range e.Authors {
+		if strings.EqualFold(author, pr.Author) {
+			return true
+		}
+	}
+
+	return matchLabels(e.Labels, pr.Labels)
+}
+
+// matchLabels reports whether any of labels is in prLabels, the `*`
+// wildcard matches everything.
+func matchLabels(labels, prLabels []string) bool {
+	for _, l := range labels {
+		if l == "*" {
+			return true
+		}
+
+		for _, pl := range prLabels {
+			if strings.EqualFold(l, pl) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// categorizeChanges buckets changes into the first category matching the
+// labels of their pull request, the way GitHub generates release notes.
+// Changes which match no category are collected in a trailing group and
+// excluded changes are dropped. Empty categories are omitted.
+func categorizeChanges(changes []change, cfg *releaseConfig) []changeGroup {
+	categories := cfg.Changelog.Categories
+	result := make([]changeGroup, len(categories)+1)
+
+	for i, category := range categories {
+		result[i].Title = category.Title
+	}
+
+	result[len(categories)].Title = otherChangesTitle
+
+	for _, c := range changes {
+		if cfg.Changelog.Exclude.excludes(c.PullRequest) {
+			continue
+		}
+
+		var labels []string
+		if c.PullRequest != nil {
+			labels = c.PullRequest.Labels
+		}
+
+		idx := len(categories)
+
+		for i, category := range categories {
+			if matchLabels(category.Labels, labels) && !category.Exclude.excludes(c.PullRequest) {
+				idx = i
+
+				break
+			}
+		}
+
+		result[idx].Changes = append(result[idx].Changes, c)
+	}
+
+	nonEmpty := result[:0]
+
+	for _, g := range result {
+		if len(g.Changes) > 0 {
+			nonEmpty = append(nonEmpty, g)
+		}
+	}
+
+	return nonEmpty
+}
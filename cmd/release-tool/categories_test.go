@@ -0,0 +1,110 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCategorizeChanges(t *testing.T) {
+	const config = `changelog:
+  exclude:
+    labels:
+      - ignore-for-release
+    authors:
+      - dependabot[bot]
+  categories:
+    - title: Breaking Changes
+      labels:
+        - breaking-change
+    - title: Features
+      labels:
+        - enhancement
+      exclude:
+        labels:
+          - experimental
+    - title: Bug Fixes
+      labels:
+        - bug
+`
+
+	var cfg releaseConfig
+
+	if err := yaml.Unmarshal([]byte(config), &cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	pr := func(author string, labels ...string) *pullRequest {
+		return &pullRequest{Author: author, Labels: labels}
+	}
+
+	changes := []change{
+		{Commit: "1", PullRequest: pr("alice", "enhancement")},
+		{Commit: "2", PullRequest: pr("bob", "Bug", "breaking-change")},
+		{Commit: "3", PullRequest: pr("carol", "bug", "ignore-for-release")},
+		{Commit: "4", PullRequest: pr("dependabot[bot]", "bug")},
+		{Commit: "5", PullRequest: pr("dave", "enhancement", "experimental")},
+		{Commit: "6"},
+		{Commit: "7", PullRequest: pr("erin", "bug")},
+	}
+
+	expected := map[string][]string{
+		"Breaking Changes": {"2"},
+		"Features":         {"1"},
+		"Bug Fixes":        {"7"},
+		otherChangesTitle:  {"5", "6"},
+	}
+	order := []string{"Breaking Changes", "Features", "Bug Fixes", otherChangesTitle}
+
+	groups := categorizeChanges(changes, &cfg)
+	if len(groups) != len(order) {
+		t.Fatalf("unexpected categories %+v", groups)
+	}
+
+	for i, g := range groups {
+		if g.Title != order[i] {
+			t.Errorf("unexpected category %q at %d, expected %q", g.Title, i, order[i])
+		}
+
+		var commits []string
+		for _, c := range g.Changes {
+			commits = append(commits, c.Commit)
+		}
+
+		if len(commits) != len(expected[g.Title]) {
+			t.Errorf("unexpected changes in %s: %v", g.Title, commits)
+
+			continue
+		}
+
+		for j := range commits {
+			if commits[j] != expected[g.Title][j] {
+				t.Errorf("unexpected changes in %s: %v", g.Title, commits)
+			}
+		}
+	}
+
+	// the wildcard catches all remaining changes
+	cfg.Changelog.Categories = append(cfg.Changelog.Categories, releaseCategory{Title: "Everything Else", Labels: []string{"*"}})
+
+	groups = categorizeChanges(changes, &cfg)
+	if last := groups[len(groups)-1]; last.Title != "Everything Else" || len(last.Changes) != 2 {
+		t.Errorf("unexpected last category %+v", last)
+	}
+}
@@ -0,0 +1,270 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+var changelogCommand = &cli.Command{
+	Name:      "changelog",
+	Usage:     "insert or replace the release notes section of the release in a changelog file",
+	ArgsUsage: "<release file>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "file",
+			Usage: "changelog file to update",
+			Value: "CHANGELOG.md",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		format := context.String("format")
+		if isDataFormat(format) {
+			return fmt.Errorf("%s output can not be added to a changelog", format)
+		}
+
+		r, err := loadReleaseFromContext(context)
+		if err != nil {
+			return err
+		}
+
+		if err = generateRelease(context, r); err != nil {
+			return err
+		}
+
+		tmpl, err := getTemplate(context)
+		if err != nil {
+			return err
+		}
+
+		var section bytes.Buffer
+
+		if err = renderRelease(&section, format, tmpl, r); err != nil {
+			return err
+		}
+
+		path := context.String("file")
+
+		existing, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		updated, replaced := updateChangelog(existing, section.Bytes(), r.Tag, r.Version)
+
+		if err = writeFileAtomic(path, updated); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+
+		if replaced {
+			logrus.Infof("replaced %s section in %s", r.Tag, path)
+		} else {
+			logrus.Infof("added %s section to %s", r.Tag, path)
+		}
+
+		return nil
+	},
+}
+
+// headingLevel returns the level of a markdown ATX heading line, or 0 if
+// line is not a heading.
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ' && line[level] != '\t') {
+		return 0
+	}
+
+	return level
+}
+
+// isTagHeading reports whether heading refers to the release tag or version.
+func isTagHeading(heading, tag, version string) bool {
+	for _, token := range []string{tag, version} {
+		if token == "" {
+			continue
+		}
+
+		for i := 0; ; {
+			idx := strings.Index(heading[i:], token)
+			if idx < 0 {
+				break
+			}
+
+			start, end := i+idx, i+idx+len(token)
+			if (start == 0 || !isVersionChar(heading[start-1])) && (end == len(heading) || !isVersionChar(heading[end])) {
+				return true
+			}
+
+			i = start + 1
+		}
+	}
+
+	return false
+}
+
+func isVersionChar(c byte) bool {
+	return c == '.' || c == '-' || c == '+' || c == '_' ||
+		(c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// updateChangelog inserts section before the first release section of the
+// changelog, after any preamble such as a title. If the changelog already
+// contains a section for the tag, it is replaced instead.
+func updateChangelog(existing, section []byte, tag, version string) ([]byte, bool) {
+	sectionText := strings.TrimSpace(string(section))
+	if len(bytes.TrimSpace(existing)) == 0 {
+		return []byte(sectionText + "\n"), false
+	}
+
+	level := 2
+
+	for _, line := range strings.Split(sectionText, "\n") {
+		if l := headingLevel(line); l > 0 {
+			level = l
+
+			break
+		}
+	}
+
+	var (
+		lines              = strings.Split(string(existing), "\n")
+		fence              string
+		insert, start, end = -1, -1, len(lines)
+	)
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+
+			continue
+		}
+
+		l := headingLevel(line)
+		if l == 0 || l > level {
+			continue
+		}
+
+		if start >= 0 {
+			end = i
+
+			break
+		}
+
+		if l != level {
+			continue
+		}
+
+		if insert < 0 {
+			insert = i
+		}
+
+		if isTagHeading(line, tag, version) {
+			start = i
+		}
+	}
+
+	var b strings.Builder
+
+	write := func(lines []string) {
+		if text := strings.TrimSpace(strings.Join(lines, "\n")); text != "" {
+			if b.Len() > 0 {
+				b.WriteString("\n\n")
+			}
+
+			b.WriteString(text)
+		}
+	}
+
+	switch {
+	case start >= 0:
+		write(lines[:start])
+		write([]string{sectionText})
+		write(lines[end:])
+	case insert >= 0:
+		write(lines[:insert])
+		write([]string{sectionText})
+		write(lines[insert:])
+	default:
+		write(lines)
+		write([]string{sectionText})
+	}
+
+	b.WriteString("\n")
+
+	return []byte(b.String()), start >= 0
+}
+
+// writeFileAtomic replaces the file at path with data by renaming a
+// temporary file, the permissions of an existing file are kept.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(f.Name()) //nolint: errcheck
+
+	if _, err = f.Write(data); err != nil {
+		f.Close() //nolint: errcheck
+
+		return err
+	}
+
+	if err = f.Chmod(mode); err != nil {
+		f.Close() //nolint: errcheck
+
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		f.Close() //nolint: errcheck
+
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
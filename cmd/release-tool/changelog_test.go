@@ -0,0 +1,92 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpdateChangelog(t *testing.T) {
+	const (
+		v110 = "## [tool 1.1.0](https://github.com/o/r/releases/tag/v1.1.0)\n\n### Changes\n\n* new\n"
+		v100 = "## [tool 1.0.0](https://github.com/o/r/releases/tag/v1.0.0)\n\n### Changes\n\n* old\n"
+	)
+
+	for _, tc := range []struct {
+		name     string
+		tag      string
+		existing string
+		section  string
+		expected string
+		replaced bool
+	}{
+		{
+			name:     "empty",
+			tag:      "v1.0.0",
+			section:  v100,
+			expected: v100,
+		},
+		{
+			name:     "prepend",
+			tag:      "v1.1.0",
+			existing: v100,
+			section:  v110,
+			expected: v110 + "\n" + v100,
+		},
+		{
+			name:     "after title",
+			tag:      "v1.1.0",
+			existing: "# Changelog\n\nAll notable changes.\n\n" + v100,
+			section:  v110,
+			expected: "# Changelog\n\nAll notable changes.\n\n" + v110 + "\n" + v100,
+		},
+		{
+			name:     "replace",
+			tag:      "v1.1.0",
+			existing: "# Changelog\n\n" + "## [tool 1.1.0](https://github.com/o/r/releases/tag/v1.1.0)\n\n* stale\n\n" + v100,
+			section:  v110,
+			expected: "# Changelog\n\n" + v110 + "\n" + v100,
+			replaced: true,
+		},
+		{
+			name:     "replace last",
+			tag:      "v1.1.0-rc.1",
+			existing: v110 + "\n## [tool 1.1.0-rc.1](https://github.com/o/r/releases/tag/v1.1.0-rc.1)\n\n```\n## [tool 1.1.0]\n```\n",
+			section:  "## [tool 1.1.0-rc.1](https://github.com/o/r/releases/tag/v1.1.0-rc.1)\n\n* updated\n",
+			expected: v110 + "\n## [tool 1.1.0-rc.1](https://github.com/o/r/releases/tag/v1.1.0-rc.1)\n\n* updated\n",
+			replaced: true,
+		},
+	} {
+		version := strings.TrimPrefix(tc.tag, "v")
+
+		updated, replaced := updateChangelog([]byte(tc.existing), []byte(tc.section), tc.tag, version)
+		if string(updated) != tc.expected {
+			t.Errorf("[%s] unexpected changelog:\n%s\nexpected:\n%s", tc.name, updated, tc.expected)
+		}
+
+		if replaced != tc.replaced {
+			t.Errorf("[%s] unexpected replaced %t", tc.name, replaced)
+		}
+
+		// updating again with the same section must not duplicate it
+		again, _ := updateChangelog(updated, []byte(tc.section), tc.tag, version)
+		if string(again) != string(updated) {
+			t.Errorf("[%s] changelog changed on second update:\n%s", tc.name, again)
+		}
+	}
+}
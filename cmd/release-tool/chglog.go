@@ -0,0 +1,218 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// chglogConfigFiles are the locations searched for a git-chglog config
+// when `chglog_config` is not set.
+var chglogConfigFiles = []string{"hack/git-chglog/config.yaml", ".chglog/config.yml", ".chglog/config.yaml"}
+
+// chglogConfig is the subset of the git-chglog config used to parse and
+// group commits.
+type chglogConfig struct {
+	Options struct {
+		Commits struct {
+			Filters map[string][]string `yaml:"filters"`
+		} `yaml:"commits"`
+		CommitGroups struct {
+			TitleMaps  map[string]string `yaml:"title_maps"`
+			TitleOrder []string          `yaml:"title_order"`
+		} `yaml:"commit_groups"`
+		Header struct {
+			Pattern     string   `yaml:"pattern"`
+			PatternMaps []string `yaml:"pattern_maps"`
+		} `yaml:"header"`
+		Notes struct {
+			Keywords []string `yaml:"keywords"`
+		} `yaml:"notes"`
+	} `yaml:"options"`
+}
+
+// loadChglogConfig reads the git-chglog config at path from rev, or the
+// first config found in the default locations when path is empty. It
+// returns nil when no config is found.
+func loadChglogConfig(rev, path string) (*chglogConfig, error) {
+	paths := chglogConfigFiles
+	if path != "" {
+		paths = []string{path}
+	}
+
+	for _, p := range paths {
+		r, err := fileFromRev(rev, p)
+		if err != nil {
+			if path != "" {
+				return nil, fmt.Errorf("unable to read 'chglog_config' %s at %s: %w", path, rev, err)
+			}
+
+			continue
+		}
+
+		cfg, err := parseChglogConfig(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", p, err)
+		}
+
+		logrus.Debugf("parsing commits with git-chglog config %s", p)
+
+		return cfg, nil
+	}
+
+	return nil, nil //nolint: nilnil
+}
+
+func parseChglogConfig(r io.Reader) (*chglogConfig, error) {
+	var cfg chglogConfig
+
+	if err := yaml.NewDecoder(r).Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// parser returns the commit parser for the header pattern and the note
+// keywords of the config, unset options keep the defaults.
+func (cfg *chglogConfig) parser() (*commitParser, error) {
+	p := *defaultCommitParser
+
+	if header := cfg.Options.Header; header.Pattern != "" {
+		re, err := regexp.Compile(header.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid header pattern: %w", err)
+		}
+
+		p.header = re
+		p.fields = header.PatternMaps
+	}
+
+	if keywords := cfg.Options.Notes.Keywords; len(keywords) > 0 {
+		p.keywords = keywords
+	}
+
+	return &p, nil
+}
+
+// changeGroups returns the change groups for the title maps of the config,
+// types with the same title share a group. Groups are ordered by
+// `title_order` and then by title like git-chglog does.
+func (cfg *chglogConfig) changeGroups() []changeGroupConfig {
+	var (
+		groups  []changeGroupConfig
+		byTitle = map[string]int{}
+		types   = make([]string, 0, len(cfg.Options.CommitGroups.TitleMaps))
+	)
+
+	for t := range cfg.Options.CommitGroups.TitleMaps {
+		types = append(types, t)
+	}
+
+	sort.Strings(types)
+
+	for _, t := range types {
+		title := cfg.Options.CommitGroups.TitleMaps[t]
+
+		idx, ok := byTitle[title]
+		if !ok {
+			idx = len(groups)
+			byTitle[title] = idx
+			groups = append(groups, changeGroupConfig{Title: title})
+		}
+
+		groups[idx].Types = append(groups[idx].Types, t)
+	}
+
+	order := map[string]int{}
+	for i, title := range cfg.Options.CommitGroups.TitleOrder {
+		order[title] = i
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		oi, iok := order[groups[i].Title]
+		oj, jok := order[groups[j].Title]
+
+		switch {
+		case iok && jok:
+			return oi < oj
+		case iok != jok:
+			return iok
+		default:
+			return groups[i].Title < groups[j].Title
+		}
+	})
+
+	return groups
+}
+
+// filterChanges returns the changes matching the commit filters of the
+// config, filters on Type and Scope are supported.
+func (cfg *chglogConfig) filterChanges(changes []change) []change {
+	if len(cfg.Options.Commits.Filters) == 0 {
+		return changes
+	}
+
+	filtered := make([]change, 0, len(changes))
+
+	for _, c := range changes {
+		if cfg.includes(c) {
+			filtered = append(filtered, c)
+		}
+	}
+
+	return filtered
+}
+
+func (cfg *chglogConfig) includes(c change) bool {
+	for field, values := range cfg.Options.Commits.Filters {
+		var value string
+
+		switch strings.ToLower(field) {
+		case "type":
+			value = c.Type
+		case "scope":
+			value = c.Scope
+		default:
+			continue
+		}
+
+		var found bool
+
+		for _, v := range values {
+			if strings.EqualFold(v, value) {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,133 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadChglogConfig(t *testing.T) {
+	testRepo(t)
+	testCommit(t, "hack/git-chglog/config.yaml", "options:\n  commits:\n    filters:\n      Type:\n        - feat\n", "add git-chglog config")
+
+	cfg, err := loadChglogConfig("HEAD", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a config found in the default locations is applied like an explicit one
+	if cfg == nil || len(cfg.Options.Commits.Filters["Type"]) != 1 {
+		t.Errorf("expected the filters of the found config, got %+v", cfg)
+	}
+
+	if cfg, err = loadChglogConfig("HEAD", "hack/git-chglog/config.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg == nil || len(cfg.Options.Commits.Filters["Type"]) != 1 {
+		t.Errorf("expected the filters of the explicit config, got %+v", cfg)
+	}
+
+	if _, err = loadChglogConfig("HEAD", ".chglog/config.yml"); err == nil {
+		t.Error("expected an error for a missing explicit config")
+	}
+}
+
+func TestChglogConfig(t *testing.T) {
+	const config = `style: github
+template: CHANGELOG.tpl.md
+options:
+  commits:
+    filters:
+      Type:
+        - feat
+        - fix
+        - perf
+  commit_groups:
+    title_maps:
+      feat: Features
+      fix: Bug Fixes
+      perf: Features
+  header:
+    pattern: "^(\\w*)(?:\\(([\\w\\$\\.\\-\\*\\s]*)\\))?\\:\\s(.*)$"
+    pattern_maps:
+      - Type
+      - Scope
+      - Subject
+  notes:
+    keywords:
+      - BREAKING CHANGE
+      - DEPRECATED
+`
+
+	cfg, err := parseChglogConfig(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parser, err := cfg.parser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := []change{
+		{Description: "feat(api): add endpoint"},
+		{Description: "fix: crash", body: "DEPRECATED: the old flag is ignored"},
+		{Description: "chore: update deps"},
+		{Description: "perf!: faster"},
+		{Description: "Merge pull request #1 from owner/branch"},
+	}
+
+	for i := range changes {
+		parser.parseConventional(&changes[i])
+	}
+
+	if c := changes[0]; c.Type != "feat" || c.Scope != "api" || c.Subject != "add endpoint" {
+		t.Errorf("unexpected header fields %+v", c)
+	}
+
+	if c := changes[1]; !c.Breaking || c.breakingNote != "the old flag is ignored" {
+		t.Errorf("expected breaking change from note keyword %+v", c)
+	}
+
+	// the breaking marker is detected without support in the header pattern
+	if c := changes[3]; c.Type != "perf" || c.Subject != "faster" || !c.Breaking {
+		t.Errorf("unexpected header fields %+v", c)
+	}
+
+	filtered := cfg.filterChanges(changes)
+	if len(filtered) != 3 || filtered[0].Type != "feat" || filtered[1].Type != "fix" || filtered[2].Type != "perf" {
+		t.Errorf("unexpected filtered changes %+v", filtered)
+	}
+
+	expected := []changeGroupConfig{
+		{Title: "Bug Fixes", Types: []string{"fix"}},
+		{Title: "Features", Types: []string{"feat", "perf"}},
+	}
+
+	if groups := cfg.changeGroups(); !reflect.DeepEqual(groups, expected) {
+		t.Errorf("unexpected groups %+v", groups)
+	}
+
+	cfg.Options.CommitGroups.TitleOrder = []string{"Features"}
+
+	if groups := cfg.changeGroups(); groups[0].Title != "Features" {
+		t.Errorf("expected title order to be used, got %+v", groups)
+	}
+}
@@ -0,0 +1,589 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// rollup is the cumulative set of changes of a release since an earlier
+// release of the same pre-release series.
+type rollup struct {
+	Label            string           `json:"label" yaml:"label"`
+	Since            string           `json:"since" yaml:"since"`
+	Changes          []projectChange  `json:"changes" yaml:"changes"`
+	ContributorNames []string         `json:"contributors" yaml:"contributors"`
+	Contributors     []contributor    `json:"contributor_details" yaml:"contributor_details"`
+	NewContributors  []newContributor `json:"new_contributors" yaml:"new_contributors"`
+	Dependencies     []dependency     `json:"dependencies" yaml:"dependencies"`
+}
+
+// releaseRange holds the changes, contributors and dependency updates
+// between two revisions of the project.
+type releaseRange struct {
+	changes         []projectChange
+	contributors    []contributor
+	newContributors []newContributor
+	dependencies    []dependency
+}
+
+// rangeCollector collects the changes of the project and its matched
+// dependencies between revisions.
+type rangeCollector struct {
+	r         *release
+	cache     Cache
+	gitRoot   string
+	tempRoot  string
+	matchDeps *regexp.Regexp
+	trackers  []tracker
+	config    *releaseConfig
+	parser    *commitParser
+	chglog    *chglogConfig
+	groups    []changeGroupConfig
+	bots      *botFilter
+	filter    *changelogFilter
+	linkify   bool
+	gfm       bool
+
+	// github fetches pull request metadata when set
+	github       *githubClient
+	pullRequests map[string]*pullRequest
+}
+
+func newRangeCollector(r *release, cache Cache, gitRoot string, linkify, gfm bool) (*rangeCollector, error) {
+	rc := &rangeCollector{
+		r:            r,
+		cache:        cache,
+		gitRoot:      gitRoot,
+		linkify:      linkify,
+		gfm:          gfm,
+		parser:       defaultCommitParser,
+		groups:       r.ChangeGroups,
+		pullRequests: map[string]*pullRequest{},
+	}
+
+	if r.MatchDeps != "" {
+		var err error
+
+		rc.matchDeps, err = regexp.Compile(r.MatchDeps)
+		if err != nil {
+			return nil, fmt.Errorf("unable to compile 'match_deps' regexp: %w", err)
+		}
+	}
+
+	trackers, err := r.orderedTrackers()
+	if err != nil {
+		return nil, err
+	}
+
+	rc.trackers = trackers
+
+	if rc.bots, err = newBotFilter(r.Bots); err != nil {
+		return nil, err
+	}
+
+	if rc.filter, err = newChangelogFilter(r.Changelog); err != nil {
+		return nil, err
+	}
+
+	if rc.config, err = loadReleaseConfig(r.Commit); err != nil {
+		return nil, err
+	}
+
+	if rc.chglog, err = loadChglogConfig(r.Commit, r.ChglogConfig); err != nil {
+		return nil, err
+	}
+
+	if rc.chglog != nil {
+		if rc.parser, err = rc.chglog.parser(); err != nil {
+			return nil, fmt.Errorf("invalid git-chglog config: %w", err)
+		}
+
+		if len(rc.groups) == 0 {
+			rc.groups = rc.chglog.changeGroups()
+		}
+	}
+
+	return rc, nil
+}
+
+// cleanup removes the temporary clone directory.
+func (rc *rangeCollector) cleanup() {
+	if rc.tempRoot != "" {
+		os.RemoveAll(rc.tempRoot) //nolint: errcheck
+	}
+}
+
+// cloneRoot returns the directory dependencies are cloned in, a temporary
+// directory is used when there is no cache directory.
+func (rc *rangeCollector) cloneRoot() (string, error) {
+	if rc.gitRoot != "" {
+		return rc.gitRoot, nil
+	}
+
+	if rc.tempRoot == "" {
+		td, err := os.MkdirTemp("", "tmp-clone-")
+		if err != nil {
+			return "", fmt.Errorf("unable to create temp clone directory: %w", err)
+		}
+
+		rc.tempRoot = td
+	}
+
+	return rc.tempRoot, nil
+}
+
+// collect returns the changes between previous and commit of the project,
+// followed by the changes of the updated dependencies matching `match_deps`.
+//
+//nolint:gocognit,gocyclo,cyclop
+func (rc *rangeCollector) collect(ctx context.Context, previous, commit string) (*releaseRange, error) {
+	var (
+		r            = rc.r
+		contributors = newContributorSet(r.ContributorOptions.Aliases, rc.bots)
+		botCommits   int
+	)
+
+	projectChanges, err := rc.projectChanges(ctx, previous, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = addContributors(previous, commit, "", contributors); err != nil {
+		return nil, err
+	}
+
+	if err = addKnownContributors(previous, contributors); err != nil {
+		return nil, err
+	}
+
+	updatedDeps, err := rc.updatedDependencies(previous, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(updatedDeps, func(i, j int) bool {
+		if updatedDeps[i].Name != updatedDeps[j].Name {
+			return updatedDeps[i].Name < updatedDeps[j].Name
+		}
+
+		return strings.Join(updatedDeps[i].Modules, " ") < strings.Join(updatedDeps[j].Modules, " ")
+	})
+
+	if rc.matchDeps != nil && len(updatedDeps) > 0 {
+		gitRoot, err := rc.cloneRoot()
+		if err != nil {
+			return nil, err
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get cwd: %w", err)
+		}
+
+		// a dependency updated to different versions by several modules
+		// has its changes collected once, from the first module
+		matched := map[string]struct{}{}
+
+		for _, dep := range updatedDeps {
+			if _, ok := matched[dep.Name]; ok {
+				continue
+			}
+
+			matches := rc.matchDeps.FindStringSubmatch(dep.Name)
+			if matches == nil {
+				continue
+			}
+
+			matched[dep.Name] = struct{}{}
+
+			logrus.Debugf("Matched dependency %s with %s", dep.Name, r.MatchDeps)
+
+			var name string
+
+			if len(matches) < 2 {
+				name = path.Base(dep.Name)
+			} else {
+				name = matches[1]
+			}
+
+			if err = os.Chdir(gitRoot); err != nil {
+				return nil, fmt.Errorf("unable to chdir to temp clone directory: %w", err)
+			}
+
+			var cloned bool
+
+			if _, err = os.Stat(name); err != nil && os.IsNotExist(err) {
+				logrus.Debugf("git clone %s %s", dep.GitURL, name)
+
+				if _, err = git("clone", dep.GitURL, name); err != nil {
+					return nil, fmt.Errorf("failed to clone: %w", err)
+				}
+
+				cloned = true
+			} else if err != nil {
+				return nil, fmt.Errorf("unable to stat: %w", err)
+			}
+
+			if err = os.Chdir(name); err != nil {
+				return nil, fmt.Errorf("unable to chdir to cloned %s directory: %w", name, err)
+			}
+
+			if !cloned {
+				if _, err = git("show", dep.Ref); err != nil {
+					logrus.WithField("name", name).Debugf("git fetch origin")
+
+					if _, err = git("fetch", "origin"); err != nil {
+						return nil, fmt.Errorf("failed to fetch: %w", err)
+					}
+				}
+			}
+
+			var changes []change
+
+			changes, err = changelog(dep.Previous, dep.Ref, rc.parser, rc.filter.paths)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get changelog for %s: %w", name, err)
+			}
+
+			if changes, err = dropShipped(dep.Previous, dep.Ref, cancelReverts(changes)); err != nil {
+				return nil, fmt.Errorf("failed to dedupe changelog for %s: %w", name, err)
+			}
+
+			changes = rc.filter.filterChanges(changes)
+
+			changes, botCommits = rc.bots.filterChanges(changes)
+
+			if err = addContributors(dep.Previous, dep.Ref, name, contributors); err != nil {
+				return nil, fmt.Errorf("failed to get authors for %s: %w", name, err)
+			}
+
+			if err = addKnownContributors(dep.Previous, contributors); err != nil {
+				return nil, fmt.Errorf("failed to get previous authors for %s: %w", name, err)
+			}
+
+			f := dependencyForge(dep, r.forge)
+
+			if err = rc.addPullRequests(ctx, f, changes); err != nil {
+				return nil, fmt.Errorf("failed to get pull requests for %s: %w", name, err)
+			}
+
+			if rc.linkify {
+				if f == nil {
+					logrus.Debugf("linkify not supported for the host of %s, skipping", dep.Name)
+				} else if err = linkifyChanges(changes, forgeCommitLink(f, rc.gfm), referenceLinks(f, nil), rc.gfm); err != nil {
+					return nil, err
+				}
+			}
+
+			projectChanges = append(projectChanges, projectChange{
+				Name:       name,
+				Changes:    changes,
+				BotCommits: botCommits,
+			})
+		}
+
+		if err = os.Chdir(cwd); err != nil {
+			return nil, fmt.Errorf("unable to chdir to previous cwd: %w", err)
+		}
+	}
+
+	for i := range projectChanges {
+		projectChanges[i].Groups = groupChanges(projectChanges[i].Changes, rc.groups)
+	}
+
+	for i := range projectChanges {
+		if rc.config != nil && projectChanges[i].Name == "" {
+			projectChanges[i].Categories = categorizeChanges(projectChanges[i].Changes, rc.config)
+		}
+	}
+
+	rng := &releaseRange{
+		changes:      projectChanges,
+		contributors: contributors.ordered(),
+		dependencies: updatedDeps,
+	}
+
+	// without a previous release every author would be new
+	if previous != "" {
+		rng.newContributors = contributors.newContributors(projectChanges)
+	}
+
+	return rng, nil
+}
+
+// projectChanges returns the changes of the project between previous and
+// commit, with a project change per component followed by the changes
+// outside of the components when components are configured.
+func (rc *rangeCollector) projectChanges(ctx context.Context, previous, commit string) ([]projectChange, error) {
+	components := rc.r.orderedComponents()
+	if len(components) == 0 {
+		changes, botCommits, err := rc.changes(ctx, previous, commit, rc.filter.paths, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return []projectChange{{Changes: changes, BotCommits: botCommits}}, nil
+	}
+
+	var projectChanges []projectChange
+
+	for _, c := range components {
+		changes, botCommits, err := rc.changes(ctx, previous, commit, c.Paths, rc.filter.paths)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get changes of component %s: %w", c.Name, err)
+		}
+
+		deps, err := componentDependencies(previous, commit, c, rc.r.IgnoreDeps, rc.cache)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dependencies of component %s: %w", c.Name, err)
+		}
+
+		if len(changes) == 0 && botCommits == 0 && len(deps) == 0 {
+			continue
+		}
+
+		projectChanges = append(projectChanges, projectChange{
+			Component:    c.Name,
+			Changes:      changes,
+			BotCommits:   botCommits,
+			Dependencies: deps,
+		})
+	}
+
+	changes, botCommits, err := rc.changes(ctx, previous, commit, otherPaths(components, rc.filter.paths), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) > 0 || botCommits > 0 {
+		projectChanges = append(projectChanges, projectChange{
+			Component:  otherComponent,
+			Changes:    changes,
+			BotCommits: botCommits,
+		})
+	}
+
+	return projectChanges, nil
+}
+
+// updatedDependencies returns the dependencies updated between previous and
+// commit in any Go module of the project and in the Makefile. The modules of
+// the components are left out, their dependencies are listed with the
+// component.
+func (rc *rangeCollector) updatedDependencies(previous, commit string) ([]dependency, error) {
+	r := rc.r
+
+	var componentModules []string
+	for _, c := range r.orderedComponents() {
+		componentModules = append(componentModules, c.modules()...)
+	}
+
+	currentModules, err := parseModules(commit)
+	if err != nil {
+		return nil, err
+	}
+
+	previousModules, err := parseModules(previous)
+	if err != nil {
+		return nil, err
+	}
+
+	currentModules = excludeModules(currentModules, componentModules)
+	previousModules = excludeModules(previousModules, componentModules)
+
+	updated, err := getUpdatedModuleDeps(previousModules, currentModules, r.orderedRenameDeps(), r.IgnoreDeps, rc.cache)
+	if err != nil {
+		return nil, err
+	}
+
+	makeDeps := r.orderedMakeDeps()
+
+	currentMakeDeps, err := parseMakeDependencies(commit, makeDeps)
+	if err != nil {
+		return nil, err
+	}
+
+	previousMakeDeps, err := parseMakeDependencies(previous, makeDeps)
+	if err != nil {
+		return nil, err
+	}
+
+	renameDependencies(previousMakeDeps, r.orderedRenameDeps())
+
+	updatedMakeDeps, err := getUpdatedDeps(previousMakeDeps, currentMakeDeps, r.IgnoreDeps, rc.cache)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(updated, updatedMakeDeps...), nil
+}
+
+// changes returns the changes of the project touching paths, and within
+// when set, between previous and commit, and the number of collapsed
+// commits of bots.
+func (rc *rangeCollector) changes(ctx context.Context, previous, commit string, paths, within []string) ([]change, int, error) {
+	r := rc.r
+
+	changes, err := changelog(previous, commit, rc.parser, paths)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(within) > 0 {
+		if changes, err = touchingPaths(previous, commit, within, changes); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if changes, err = dropShipped(previous, commit, cancelReverts(changes)); err != nil {
+		return nil, 0, err
+	}
+
+	changes = rc.filter.filterChanges(changes)
+
+	if rc.chglog != nil {
+		changes = rc.chglog.filterChanges(changes)
+	}
+
+	changes, botCommits := rc.bots.filterChanges(changes)
+
+	if err = rc.addPullRequests(ctx, r.forge, changes); err != nil {
+		return nil, 0, err
+	}
+
+	if rc.linkify {
+		if r.forge == nil {
+			logrus.Debug("no repository for the project, skipping linkify")
+		} else if err = linkifyChanges(changes, forgeCommitLink(r.forge, rc.gfm), referenceLinks(r.forge, rc.trackers), rc.gfm); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return changes, botCommits, nil
+}
+
+// addPullRequests sets the pull request of the changes merged through a
+// GitHub pull request, pull requests are only fetched once.
+func (rc *rangeCollector) addPullRequests(ctx context.Context, f forge, changes []change) error {
+	if rc.github == nil || f == nil || f.kind() != forgeGitHub {
+		return nil
+	}
+
+	for i := range changes {
+		number := pullRequestNumber(f, changes[i])
+		if number == "" {
+			continue
+		}
+
+		key := f.repo() + "#" + number
+
+		pr, ok := rc.pullRequests[key]
+		if !ok {
+			var err error
+
+			pr, err = rc.github.pullRequest(ctx, f.repo(), number, rc.cache)
+			if err != nil {
+				return fmt.Errorf("failed to get pull request %s: %w", key, err)
+			}
+
+			rc.pullRequests[key] = pr
+		}
+
+		if pr == nil {
+			logrus.Debugf("%s is not a merged pull request", key)
+
+			continue
+		}
+
+		// the merge commit of merged and squash merged pull requests is the
+		// commit itself, cherry-picks and follow-up commits referring to
+		// the pull request do not match
+		if pr.MergeCommit != changes[i].hash {
+			logrus.Debugf("pull request %s was merged as %s, not in %s, skipping", key, pr.MergeCommit, changes[i].hash)
+
+			continue
+		}
+
+		changes[i].PullRequest = pr
+	}
+
+	return nil
+}
+
+// rollups collects the cumulative changes since the last pre-release of
+// the same version and since the last stable release before the tag. Ranges which start at the
+// previous release of the release file are already covered and skipped.
+func (rc *rangeCollector) rollups(ctx context.Context) ([]rollup, error) {
+	var (
+		r       = rc.r
+		rollups []rollup
+	)
+
+	lastPreRelease, err := getPreviousPreRelease(r.Tag, r.Commit)
+	if err != nil {
+		return nil, err
+	}
+
+	lastStable, err := getPreviousTag(r.Tag, r.Commit, true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, since := range []struct {
+		tag   string
+		label string
+	}{
+		{tag: lastPreRelease, label: "last pre-release"},
+		{tag: lastStable, label: "last stable release"},
+	} {
+		if since.tag == "" || since.tag == r.Previous {
+			continue
+		}
+
+		rng, err := rc.collect(ctx, since.tag, r.Commit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect changes since %s: %w", since.tag, err)
+		}
+
+		for i := range rng.changes {
+			rng.changes[i].Since = since.tag
+		}
+
+		logrus.Infof("including changes since %s %s", since.label, since.tag)
+
+		rollups = append(rollups, rollup{
+			Label:            since.label,
+			Since:            since.tag,
+			Changes:          rng.changes,
+			Contributors:     rng.contributors,
+			ContributorNames: contributorNames(rng.contributors),
+			NewContributors:  rng.newContributors,
+			Dependencies:     rng.dependencies,
+		})
+	}
+
+	return rollups, nil
+}
@@ -0,0 +1,79 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRollups(t *testing.T) {
+	testRepo(t)
+
+	for _, c := range []struct {
+		file, msg, tag string
+	}{
+		{"a", "feat: first", "v1.4.0"},
+		{"b", "fix: backport", "v1.4.1-rc.1"},
+		{"c", "feat: second", "v1.5.0-rc.1"},
+		{"d", "feat: third", ""},
+	} {
+		testCommit(t, c.file, c.msg+"\n", c.msg)
+
+		if c.tag == "" {
+			continue
+		}
+
+		if _, err := git("tag", c.tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		tag, previous string
+		commit        string
+		expected      []string
+	}{
+		// pre-releases of other versions are not rolled up
+		{"v1.5.0-rc.1", "v1.4.0", "v1.5.0-rc.1", nil},
+		{"v1.5.0-rc.2", "v1.5.0-rc.1", "HEAD", []string{"last stable release v1.4.0"}},
+		{"v1.5.0", "v1.4.0", "HEAD", []string{"last pre-release v1.5.0-rc.1"}},
+		{"v1.4.1", "v1.4.0", "v1.4.1-rc.1", []string{"last pre-release v1.4.1-rc.1"}},
+	} {
+		r := &release{Tag: tc.tag, Previous: tc.previous, Commit: tc.commit}
+
+		rc, err := newRangeCollector(r, nilCache{}, "", false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rollups, err := rc.rollups(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var actual []string
+		for _, rollup := range rollups {
+			actual = append(actual, rollup.Label+" "+rollup.Since)
+		}
+
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%s: unexpected rollups %q, expected %q", tc.tag, actual, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// otherComponent is the component of the changes outside of the paths of
+// all components.
+const otherComponent = "other"
+
+// component is a part of the project in subdirectories, such as a Go
+// module, with its own changes.
+type component struct {
+	Name  string   `toml:"-" json:"name" yaml:"name"`
+	Paths []string `toml:"paths" json:"paths" yaml:"paths"`
+}
+
+// orderedComponents returns the components in declaration order.
+func (r *release) orderedComponents() []component {
+	names := orderedKeys(r.meta, "components", r.Components)
+	components := make([]component, 0, len(names))
+
+	for _, name := range names {
+		c := r.Components[name]
+		c.Name = name
+		components = append(components, c)
+	}
+
+	return components
+}
+
+// otherPaths returns the pathspecs of the changes outside of the
+// components, limited to paths when set.
+func otherPaths(components []component, paths []string) []string {
+	other := append([]string(nil), paths...)
+	if len(other) == 0 {
+		other = append(other, ".")
+	}
+
+	for _, c := range components {
+		for _, p := range c.Paths {
+			other = append(other, ":(exclude)"+p)
+		}
+	}
+
+	return other
+}
+
+// modules returns the go.mod files in the directories of the component,
+// paths with wildcards are skipped.
+func (c component) modules() []string {
+	var modules []string
+
+	for _, p := range c.Paths {
+		if strings.ContainsAny(p, "*?[") || strings.HasPrefix(p, ":") {
+			continue
+		}
+
+		modules = append(modules, path.Join(p, goMod))
+	}
+
+	return modules
+}
+
+// componentDependencies returns the dependencies updated between previous
+// and commit in the go.mod files of the component.
+func componentDependencies(previous, commit string, c component, ignored []string, cache Cache) ([]dependency, error) {
+	var updated []dependency
+
+	for _, mod := range c.modules() {
+		current, err := goModDependencies(commit, mod)
+		if err != nil {
+			return nil, err
+		}
+
+		if current == nil {
+			continue
+		}
+
+		var prev []dependency
+
+		if previous != "" {
+			if prev, err = goModDependencies(previous, mod); err != nil {
+				return nil, err
+			}
+		}
+
+		deps, err := getUpdatedDeps(prev, current, ignored, cache)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get updated dependencies of %s: %w", mod, err)
+		}
+
+		updated = append(updated, deps...)
+	}
+
+	sort.Slice(updated, func(i, j int) bool {
+		return updated[i].Name < updated[j].Name
+	})
+
+	return updated, nil
+}
+
+// goModDependencies returns the dependencies of the go.mod file at rev, or
+// nil if the file does not exist.
+func goModDependencies(rev, file string) ([]dependency, error) {
+	rd, err := fileFromRev(rev, file)
+	if err != nil {
+		return nil, nil //nolint: nilerr
+	}
+
+	deps, err := parseGoModDependencies(rd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s at %s: %w", file, rev, err)
+	}
+
+	return deps, nil
+}
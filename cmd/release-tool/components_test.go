@@ -0,0 +1,126 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestComponentChanges(t *testing.T) {
+	testRepo(t)
+	testCommit(t, "go.mod", "module example.com/project\n", "initial commit")
+	testCommit(t, "api/go.mod", "module example.com/project/api\n", "api: add module")
+
+	if _, err := git("tag", "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	testCommit(t, "api/types.proto", "syntax = \"proto3\";\n", "api: add types")
+	testCommit(t, "api/go.mod", "module example.com/project/api\n\nrequire example.com/dep v1.0.0\n", "api: add dependency")
+	testCommit(t, "main.go", "package main\n", "feat: add main")
+	testCommit(t, "README.md", "project\n", "docs: add readme")
+
+	var r release
+
+	md, err := toml.Decode(`
+commit = "HEAD"
+previous = "v1.0.0"
+
+[components.api]
+paths = ["api/"]
+
+[components.docs]
+paths = ["*.md"]
+
+[components.empty]
+paths = ["empty/"]
+`, &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.meta = md
+
+	rc, err := newRangeCollector(&r, nilCache{}, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	projectChanges, err := rc.projectChanges(context.Background(), r.Previous, r.Commit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type section struct {
+		component string
+		changes   []string
+		deps      []string
+	}
+
+	var actual []section
+
+	for _, pc := range projectChanges {
+		s := section{component: pc.Component}
+
+		for _, c := range pc.Changes {
+			s.changes = append(s.changes, c.Description)
+		}
+
+		for _, d := range pc.Dependencies {
+			s.deps = append(s.deps, d.Name+"@"+d.Ref)
+		}
+
+		actual = append(actual, s)
+	}
+
+	expected := []section{
+		{component: "api", changes: []string{"api: add dependency", "api: add types"}, deps: []string{"example.com/dep@v1.0.0"}},
+		{component: "docs", changes: []string{"docs: add readme"}},
+		{component: otherComponent, changes: []string{"feat: add main"}},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected project changes\n got: %+v\nwant: %+v", actual, expected)
+	}
+
+	// the changelog paths apply to the components as well
+	r.Changelog.Paths = []string{"*.proto", "*.md"}
+
+	if rc, err = newRangeCollector(&r, nilCache{}, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if projectChanges, err = rc.projectChanges(context.Background(), r.Previous, r.Commit); err != nil {
+		t.Fatal(err)
+	}
+
+	var components []string
+
+	for _, pc := range projectChanges {
+		for _, c := range pc.Changes {
+			components = append(components, pc.Component+": "+c.Description)
+		}
+	}
+
+	if expected := []string{"api: api: add types", "docs: docs: add readme"}; !reflect.DeepEqual(components, expected) {
+		t.Errorf("unexpected changes within the changelog paths\n got: %v\nwant: %v", components, expected)
+	}
+}
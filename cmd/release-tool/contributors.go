@@ -0,0 +1,320 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"maps"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// contributor is a commit author, identities of the same person are merged
+// by their GitHub login or email.
+type contributor struct {
+	Name    string `json:"name" yaml:"name"`
+	Email   string `json:"email" yaml:"email"`
+	Login   string `json:"login" yaml:"login"`
+	Commits int    `json:"commits" yaml:"commits"`
+}
+
+// String returns the name of the contributor, so templates can render
+// contributors directly.
+func (c contributor) String() string {
+	return c.Name
+}
+
+type contributorOptions struct {
+	// Aliases maps GitHub logins to the emails and names the person
+	// commits with.
+	Aliases map[string][]string `toml:"aliases" json:"aliases" yaml:"aliases"`
+}
+
+// newContributor is a contributor without commits before the release.
+type newContributor struct {
+	contributor `yaml:",inline"`
+
+	// FirstCommit is the earliest change of the contributor in the
+	// release, changes of the project come before dependencies.
+	FirstCommit change `json:"first_commit" yaml:"first_commit"`
+}
+
+// noreplyEmail matches the GitHub noreply emails, with or without the
+// leading user id.
+var noreplyEmail = regexp.MustCompile(`^(?:[0-9]+\+)?([a-zA-Z0-9][a-zA-Z0-9-]*)@users\.noreply\.github\.com$`)
+
+// contributorEntry is a contributor with the identities it was merged
+// from and its earliest commit.
+type contributorEntry struct {
+	contributor
+
+	identities   map[string]struct{}
+	first        string
+	firstProject string
+}
+
+// contributorSet counts the commits of contributors. Authors are already
+// merged by the mailmap of the repository through `git log`, they are
+// further merged by login, from the aliases or a noreply email, and by
+// email. Bots are not counted and authors of the history before the
+// release are tracked to find the new contributors.
+type contributorSet struct {
+	bots         *botFilter
+	logins       map[string]string
+	contributors map[string]*contributorEntry
+	known        map[string]struct{}
+}
+
+func newContributorSet(aliases map[string][]string, bots *botFilter) *contributorSet {
+	logins := map[string]string{}
+
+	for login, identities := range aliases {
+		for _, id := range identities {
+			logins[strings.ToLower(id)] = login
+		}
+	}
+
+	return &contributorSet{
+		bots:         bots,
+		logins:       logins,
+		contributors: map[string]*contributorEntry{},
+		known:        map[string]struct{}{},
+	}
+}
+
+// login returns the GitHub login of the author, if known.
+func (cs *contributorSet) login(name, email string) string {
+	if login, ok := cs.logins[strings.ToLower(email)]; ok {
+		return login
+	}
+
+	if login, ok := cs.logins[strings.ToLower(name)]; ok {
+		return login
+	}
+
+	if m := noreplyEmail.FindStringSubmatch(email); m != nil {
+		return m[1]
+	}
+
+	return ""
+}
+
+// identities returns the keys identifying the author.
+func (cs *contributorSet) identities(name, email string) []string {
+	ids := []string{"email:" + strings.ToLower(email), "name:" + strings.ToLower(name)}
+
+	if login := cs.login(name, email); login != "" {
+		ids = append(ids, "login:"+strings.ToLower(login))
+	}
+
+	return ids
+}
+
+// add counts the commit with hash in project of the author, the most
+// recent name and real email of a contributor are kept when commits are
+// added newest first.
+func (cs *contributorSet) add(name, email, project, hash string) {
+	if cs.bots != nil && cs.bots.isBot(name, email) {
+		return
+	}
+
+	login := cs.login(name, email)
+
+	key := "email:" + strings.ToLower(email)
+	if login != "" {
+		key = "login:" + strings.ToLower(login)
+	}
+
+	c, ok := cs.contributors[key]
+	if !ok {
+		c = &contributorEntry{
+			contributor: contributor{
+				Name:  name,
+				Email: email,
+				Login: login,
+			},
+			identities:   map[string]struct{}{},
+			firstProject: project,
+		}
+		cs.contributors[key] = c
+	} else if noreplyEmail.MatchString(c.Email) && !noreplyEmail.MatchString(email) {
+		c.Email = email
+	}
+
+	c.Commits++
+	for _, id := range cs.identities(name, email) {
+		c.identities[id] = struct{}{}
+	}
+
+	if c.firstProject == project {
+		c.first = hash
+	}
+}
+
+// addKnown records an author of a commit before the release.
+func (cs *contributorSet) addKnown(name, email string) {
+	for _, id := range cs.identities(name, email) {
+		cs.known[id] = struct{}{}
+	}
+}
+
+// merged returns copies of the contributors ordered by number of commits.
+// Authors without a login are merged into the only contributor with a
+// login and the same name, to merge commits from a noreply email with the
+// others.
+func (cs *contributorSet) merged() []*contributorEntry {
+	var (
+		all    = make([]*contributorEntry, 0, len(cs.contributors))
+		byName = map[string]*contributorEntry{}
+	)
+
+	for _, c := range cs.contributors {
+		c := *c
+		c.identities = maps.Clone(c.identities)
+		all = append(all, &c)
+
+		if c.Login == "" {
+			continue
+		}
+
+		name := strings.ToLower(c.Name)
+		if _, ok := byName[name]; ok {
+			byName[name] = nil
+		} else {
+			byName[name] = &c
+		}
+	}
+
+	kept := make([]*contributorEntry, 0, len(all))
+
+	for _, c := range all {
+		if other := byName[strings.ToLower(c.Name)]; c.Login == "" && other != nil {
+			other.Commits += c.Commits
+			maps.Copy(other.identities, c.identities)
+
+			if noreplyEmail.MatchString(other.Email) {
+				other.Email = c.Email
+			}
+
+			if other.firstProject != "" && c.firstProject == "" {
+				other.first, other.firstProject = c.first, c.firstProject
+			}
+
+			continue
+		}
+
+		kept = append(kept, c)
+	}
+
+	sort.Slice(kept, func(i, j int) bool {
+		if kept[i].Commits != kept[j].Commits {
+			return kept[i].Commits > kept[j].Commits
+		}
+
+		if kept[i].Name != kept[j].Name {
+			return kept[i].Name < kept[j].Name
+		}
+
+		return kept[i].Email < kept[j].Email
+	})
+
+	return kept
+}
+
+// ordered returns the contributors ordered by number of commits.
+func (cs *contributorSet) ordered() []contributor {
+	merged := cs.merged()
+	contributors := make([]contributor, len(merged))
+
+	for i, c := range merged {
+		logrus.Debugf("Contributor: %s <%s> with %d commits", c.Name, c.Email, c.Commits)
+		contributors[i] = c.contributor
+	}
+
+	return contributors
+}
+
+// contributorNames returns the names of contributors, the release data
+// lists contributors by name for compatibility.
+func contributorNames(contributors []contributor) []string {
+	names := make([]string, 0, len(contributors))
+	for _, c := range contributors {
+		names = append(names, c.Name)
+	}
+
+	return names
+}
+
+// newContributors returns the contributors which are not authors of any
+// commit before the release, with their first change found in changes.
+func (cs *contributorSet) newContributors(changes []projectChange) []newContributor {
+	var contributors []newContributor
+
+	for _, c := range cs.merged() {
+		if cs.isKnown(c) {
+			continue
+		}
+
+		first := change{
+			Commit:  shortHash(c.first),
+			Project: c.firstProject,
+			hash:    c.first,
+		}
+
+		for _, pc := range changes {
+			if pc.Name != c.firstProject {
+				continue
+			}
+
+			for _, ch := range pc.Changes {
+				if ch.hash == c.first {
+					first = ch
+					first.Project = pc.Name
+				}
+			}
+		}
+
+		logrus.Debugf("New contributor: %s <%s> with first commit %s", c.Name, c.Email, c.first)
+
+		contributors = append(contributors, newContributor{
+			contributor: c.contributor,
+			FirstCommit: first,
+		})
+	}
+
+	return contributors
+}
+
+func (cs *contributorSet) isKnown(c *contributorEntry) bool {
+	for id := range c.identities {
+		if _, ok := cs.known[id]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+
+	return hash
+}
@@ -0,0 +1,150 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestContributorSet(t *testing.T) {
+	type author struct {
+		name, email string
+	}
+
+	for _, tc := range []struct {
+		name     string
+		aliases  map[string][]string
+		authors  []author
+		expected []contributor
+	}{
+		{
+			name: "ByEmail",
+			authors: []author{
+				{"Jane Doe", "jane@example.com"},
+				{"Jane", "Jane@example.com"},
+				{"John", "john@example.com"},
+			},
+			expected: []contributor{
+				{Name: "Jane Doe", Email: "jane@example.com", Commits: 2},
+				{Name: "John", Email: "john@example.com", Commits: 1},
+			},
+		},
+		{
+			name: "Noreply",
+			authors: []author{
+				{"Jane Doe", "12345+janedoe@users.noreply.github.com"},
+				{"Jane D", "janedoe@users.noreply.github.com"},
+				{"Jane Doe", "jane@example.com"},
+				{"Jane Doe", "jane@example.com"},
+			},
+			expected: []contributor{
+				{Name: "Jane Doe", Email: "jane@example.com", Login: "janedoe", Commits: 4},
+			},
+		},
+		{
+			name: "AmbiguousName",
+			authors: []author{
+				{"Alex", "1+alex1@users.noreply.github.com"},
+				{"Alex", "2+alex2@users.noreply.github.com"},
+				{"Alex", "alex@example.com"},
+			},
+			expected: []contributor{
+				{Name: "Alex", Email: "1+alex1@users.noreply.github.com", Login: "alex1", Commits: 1},
+				{Name: "Alex", Email: "2+alex2@users.noreply.github.com", Login: "alex2", Commits: 1},
+				{Name: "Alex", Email: "alex@example.com", Commits: 1},
+			},
+		},
+		{
+			name: "Aliases",
+			aliases: map[string][]string{
+				"jdoe": {"jane@work.example.com", "J. Doe"},
+			},
+			authors: []author{
+				{"Jane Doe", "jane@work.example.com"},
+				{"J. Doe", "jane@example.com"},
+				{"John", "john@example.com"},
+			},
+			expected: []contributor{
+				{Name: "Jane Doe", Email: "jane@work.example.com", Login: "jdoe", Commits: 2},
+				{Name: "John", Email: "john@example.com", Commits: 1},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := newContributorSet(tc.aliases, nil)
+			for i, a := range tc.authors {
+				cs.add(a.name, a.email, "", strconv.Itoa(i))
+			}
+
+			if actual := cs.ordered(); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("unexpected contributors\n got: %+v\nwant: %+v", actual, tc.expected)
+			}
+
+			if actual := cs.ordered(); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("ordering twice changed the contributors: %+v", actual)
+			}
+		})
+	}
+}
+
+func TestNewContributors(t *testing.T) {
+	cs := newContributorSet(map[string][]string{"jdoe": {"jane@work.example.com"}}, nil)
+
+	// commits are added newest first
+	cs.add("Jane Doe", "jane@work.example.com", "", "c3")
+	cs.add("Jane Doe", "1+jdoe@users.noreply.github.com", "", "c2")
+	cs.add("New Person", "new@example.com", "", "c2")
+	cs.add("New Person", "new@example.com", "", "c1")
+	cs.add("Dep Author", "dep@example.com", "dep", "d2")
+	cs.add("Dep Author", "dep@example.com", "dep", "d1")
+	cs.add("Renamed", "renamed@example.com", "", "c0")
+	cs.add("Unlisted", "unlisted@example.com", "", "c4")
+
+	cs.addKnown("J. Doe", "jane@work.example.com")
+	cs.addKnown("Renamed", "old@example.com")
+
+	changes := []projectChange{
+		{Changes: []change{
+			{Commit: "c1", Description: "first change", hash: "c1"},
+			{Commit: "c2", Description: "second change", hash: "c2"},
+		}},
+		{Name: "dep", Changes: []change{
+			{Commit: "d1", Description: "dependency change", hash: "d1"},
+		}},
+	}
+
+	expected := []newContributor{
+		{
+			contributor: contributor{Name: "Dep Author", Email: "dep@example.com", Commits: 2},
+			FirstCommit: change{Commit: "d1", Description: "dependency change", Project: "dep", hash: "d1"},
+		},
+		{
+			contributor: contributor{Name: "New Person", Email: "new@example.com", Commits: 2},
+			FirstCommit: change{Commit: "c1", Description: "first change", hash: "c1"},
+		},
+		{
+			contributor: contributor{Name: "Unlisted", Email: "unlisted@example.com", Commits: 1},
+			FirstCommit: change{Commit: "c4", hash: "c4"},
+		},
+	}
+
+	if actual := cs.newContributors(changes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected new contributors\n got: %+v\nwant: %+v", actual, expected)
+	}
+}
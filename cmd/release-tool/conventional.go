@@ -0,0 +1,225 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+const otherChangesTitle = "Other Changes"
+
+// commitParser parses the conventional commit fields of changes, the
+// submatches of header are assigned to the change fields named by fields
+// and a footer starting with one of keywords marks a breaking change.
+type commitParser struct {
+	header   *regexp.Regexp
+	fields   []string
+	keywords []string
+}
+
+// defaultCommitParser matches `type(scope)!: subject` commit headers, it
+// uses the header pattern of git-chglog with support for the breaking marker.
+var defaultCommitParser = &commitParser{
+	header:   regexp.MustCompile(`^(\w+)(?:\(([\w\$\.\-\*\s/,]*)\))?(!)?:\s+(.*)$`),
+	fields:   []string{"Type", "Scope", "Breaking", "Subject"},
+	keywords: []string{"BREAKING CHANGE", "BREAKING-CHANGE"},
+}
+
+// breakingMarker matches the `!` breaking change marker of a commit header,
+// it is detected whatever the header pattern so that patterns without the
+// marker, like the ones of git-chglog configs, still detect breaking changes.
+var breakingMarker = regexp.MustCompile(`^(\w+(?:\([^)]*\))?)!:`)
+
+// changeGroupConfig maps conventional commit types to a titled group.
+type changeGroupConfig struct {
+	Title string   `toml:"title" json:"title" yaml:"title"`
+	Types []string `toml:"types" json:"types" yaml:"types"`
+}
+
+type changeGroup struct {
+	Title   string   `json:"title" yaml:"title"`
+	Changes []change `json:"changes" yaml:"changes"`
+}
+
+var defaultChangeGroups = []changeGroupConfig{
+	{Title: "Features", Types: []string{"feat"}},
+	{Title: "Bug Fixes", Types: []string{"fix"}},
+	{Title: "Performance Improvements", Types: []string{"perf"}},
+	{Title: "Code Refactoring", Types: []string{"refactor"}},
+}
+
+// parseConventional fills in the conventional commit fields of c from its
+// description and body, non-conventional commits only get a subject.
+func (p *commitParser) parseConventional(c *change) {
+	c.Subject = c.Description
+
+	header := c.Description
+	if loc := breakingMarker.FindStringSubmatchIndex(header); loc != nil {
+		c.Breaking = true
+		header = header[:loc[3]] + header[loc[3]+1:]
+	}
+
+	if m := p.header.FindStringSubmatch(header); m != nil {
+		for i, field := range p.fields {
+			if i+1 >= len(m) {
+				break
+			}
+
+			switch value := m[i+1]; strings.ToLower(field) {
+			case "type":
+				c.Type = strings.ToLower(value)
+			case "scope":
+				c.Scope = strings.TrimSpace(value)
+			case "subject":
+				c.Subject = value
+			case "breaking":
+				c.Breaking = c.Breaking || value != ""
+			}
+		}
+	}
+
+	if note, ok := p.parseBreakingFooter(c.body); ok {
+		c.Breaking = true
+		c.breakingNote = note
+	}
+}
+
+// groupChanges buckets changes by their type into the configured groups in
+// order, changes which match no group are collected in a trailing group.
+// Empty groups are omitted.
+func groupChanges(changes []change, groups []changeGroupConfig) []changeGroup {
+	if len(groups) == 0 {
+		groups = defaultChangeGroups
+	}
+
+	var (
+		byType = map[string]int{}
+		result = make([]changeGroup, len(groups)+1)
+	)
+
+	for i, g := range groups {
+		result[i].Title = g.Title
+
+		for _, t := range g.Types {
+			t = strings.ToLower(t)
+			if _, ok := byType[t]; !ok {
+				byType[t] = i
+			}
+		}
+	}
+
+	result[len(groups)].Title = otherChangesTitle
+
+	for _, c := range changes {
+		idx, ok := byType[c.Type]
+		if !ok || c.Type == "" {
+			idx = len(groups)
+		}
+
+		result[idx].Changes = append(result[idx].Changes, c)
+	}
+
+	nonEmpty := result[:0]
+
+	for _, g := range result {
+		if len(g.Changes) > 0 {
+			nonEmpty = append(nonEmpty, g)
+		}
+	}
+
+	return nonEmpty
+}
+
+// parseBreakingFooter returns the description of a breaking change footer
+// in a commit body, the description ends at the next blank line.
+func (p *commitParser) parseBreakingFooter(body string) (string, bool) {
+	var (
+		found bool
+		note  []string
+	)
+
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+
+		if found {
+			if line == "" {
+				break
+			}
+
+			note = append(note, line)
+
+			continue
+		}
+
+		for _, keyword := range p.keywords {
+			if strings.HasPrefix(line, keyword+":") {
+				found = true
+
+				if rest := strings.TrimSpace(line[len(keyword)+1:]); rest != "" {
+					note = append(note, rest)
+				}
+
+				break
+			}
+		}
+	}
+
+	return strings.Join(note, " "), found
+}
+
+// addBreakingChanges appends the breaking changes from the changelog of
+// project to the ordered breaking changes, changes which are already listed
+// by commit are skipped.
+func (r *release) addBreakingChanges(project string, changes []change) {
+	for _, c := range changes {
+		if !c.Breaking || r.listsBreakingChange(project, c.hash) {
+			continue
+		}
+
+		description := c.breakingNote
+		if description == "" {
+			description = c.Description
+		}
+
+		r.OrderedBreakingChanges = append(r.OrderedBreakingChanges, change{
+			Commit:      c.Commit,
+			Description: description,
+			Project:     project,
+			Type:        c.Type,
+			Scope:       c.Scope,
+			Subject:     c.Subject,
+			Breaking:    true,
+			hash:        c.hash,
+		})
+	}
+}
+
+// listsBreakingChange reports whether a breaking change for hash is already listed.
+func (r *release) listsBreakingChange(project, hash string) bool {
+	for _, c := range r.OrderedBreakingChanges {
+		if c.Project != project {
+			continue
+		}
+
+		if c.hash == hash || (c.hash == "" && len(c.Commit) >= 7 && strings.HasPrefix(hash, c.Commit)) {
+			return true
+		}
+	}
+
+	return false
+}
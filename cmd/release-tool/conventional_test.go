@@ -0,0 +1,84 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestParseConventional(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		typ         string
+		scope       string
+		subject     string
+		breaking    bool
+	}{
+		{"feat: add publish command", "feat", "", "add publish command", false},
+		{"fix(tag): refuse dirty worktree", "fix", "tag", "refuse dirty worktree", false},
+		{"Feat(api)!: drop v1 endpoints", "feat", "api", "drop v1 endpoints", true},
+		{"refactor!: rename flags", "refactor", "", "rename flags", true},
+		{"Merge pull request #12 from foo/bar", "", "", "Merge pull request #12 from foo/bar", false},
+		{"docs:missing space", "", "", "docs:missing space", false},
+	} {
+		c := change{Description: tc.description}
+		defaultCommitParser.parseConventional(&c)
+
+		if c.Type != tc.typ || c.Scope != tc.scope || c.Subject != tc.subject || c.Breaking != tc.breaking {
+			t.Errorf("[%s] unexpected parse %q %q %q %t", tc.description, c.Type, c.Scope, c.Subject, c.Breaking)
+		}
+	}
+}
+
+func TestGroupChanges(t *testing.T) {
+	changes := []change{
+		{Commit: "1", Type: "fix"},
+		{Commit: "2", Type: "chore"},
+		{Commit: "3", Type: "feat"},
+		{Commit: "4"},
+		{Commit: "5", Type: "fix"},
+	}
+
+	groups := groupChanges(changes, []changeGroupConfig{
+		{Title: "Bug Fixes", Types: []string{"FIX"}},
+		{Title: "Documentation", Types: []string{"docs"}},
+		{Title: "Features", Types: []string{"feat"}},
+	})
+
+	expected := []struct {
+		title   string
+		commits []string
+	}{
+		{"Bug Fixes", []string{"1", "5"}},
+		{"Features", []string{"3"}},
+		{otherChangesTitle, []string{"2", "4"}},
+	}
+
+	if len(groups) != len(expected) {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+
+	for i, g := range groups {
+		if g.Title != expected[i].title || len(g.Changes) != len(expected[i].commits) {
+			t.Fatalf("[%d] unexpected group %+v", i, g)
+		}
+
+		for j, c := range g.Changes {
+			if c.Commit != expected[i].commits[j] {
+				t.Errorf("[%d] unexpected commit %s, expected %s", i, c.Commit, expected[i].commits[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// revertedCommit matches the body of commits created by `git revert`.
+	revertedCommit = regexp.MustCompile(`This reverts commit ([0-9a-f]{7,40})`)
+
+	// cherryPickedCommit matches the trailer of commits cherry-picked with
+	// `git cherry-pick -x`.
+	cherryPickedCommit = regexp.MustCompile(`\(cherry picked from commit ([0-9a-f]{7,40})\)`)
+)
+
+// cancelReverts drops the commits reverted within the changes along with
+// their revert. A revert of a revert which was cancelled is kept, since
+// it reapplies the original commit.
+func cancelReverts(changes []change) []change {
+	var (
+		cancelled = map[string]struct{}{}
+		found     bool
+	)
+
+	// changes are ordered newest first, reverts are matched oldest first
+	for i := len(changes) - 1; i >= 0; i-- {
+		m := revertedCommit.FindStringSubmatch(changes[i].body)
+		if m == nil {
+			continue
+		}
+
+		for j := i + 1; j < len(changes); j++ {
+			if !strings.HasPrefix(changes[j].hash, m[1]) {
+				continue
+			}
+
+			if _, ok := cancelled[changes[j].hash]; ok {
+				break
+			}
+
+			logrus.Debugf("%s reverts %s, dropping both", changes[i].Commit, changes[j].Commit)
+
+			cancelled[changes[i].hash] = struct{}{}
+			cancelled[changes[j].hash] = struct{}{}
+			found = true
+
+			break
+		}
+	}
+
+	if !found {
+		return changes
+	}
+
+	kept := make([]change, 0, len(changes)-len(cancelled))
+
+	for _, c := range changes {
+		if _, ok := cancelled[c.hash]; !ok {
+			kept = append(kept, c)
+		}
+	}
+
+	return kept
+}
+
+// dropShipped drops the changes which already shipped in previous, which
+// happens when previous is not an ancestor of commit, such as a tag of a
+// release branch. A change shipped when previous has a commit with the
+// same patch id, a commit cherry-picked from the change, or the commit the
+// change was cherry-picked from.
+func dropShipped(previous, commit string, changes []change) ([]change, error) {
+	if previous == "" || len(changes) == 0 {
+		return changes, nil
+	}
+
+	raw, err := git("rev-list", "--cherry-pick", "--right-only", previous+"..."+commit)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := map[string]struct{}{}
+	for _, hash := range strings.Fields(string(raw)) {
+		unique[hash] = struct{}{}
+	}
+
+	raw, err = git("log", "--format=%b", commit+".."+previous)
+	if err != nil {
+		return nil, err
+	}
+
+	var picked []string
+	for _, m := range cherryPickedCommit.FindAllStringSubmatch(string(raw), -1) {
+		picked = append(picked, m[1])
+	}
+
+	kept := make([]change, 0, len(changes))
+
+	for _, c := range changes {
+		if _, ok := unique[c.hash]; !ok {
+			logrus.Debugf("%s has the same patch as a commit of %s, dropping", c.Commit, previous)
+
+			continue
+		}
+
+		if hasPrefix(c.hash, picked) {
+			logrus.Debugf("%s was cherry-picked in %s, dropping", c.Commit, previous)
+
+			continue
+		}
+
+		if m := cherryPickedCommit.FindStringSubmatch(c.body); m != nil {
+			if _, err := git("merge-base", "--is-ancestor", m[1], previous); err == nil {
+				logrus.Debugf("%s is a cherry-pick of %s from %s, dropping", c.Commit, m[1], previous)
+
+				continue
+			}
+		}
+
+		kept = append(kept, c)
+	}
+
+	return kept, nil
+}
+
+func hasPrefix(hash string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(hash, p) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,124 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCancelReverts(t *testing.T) {
+	var (
+		a = strings.Repeat("a", 40)
+		b = strings.Repeat("b", 40)
+		c = strings.Repeat("c", 40)
+		d = strings.Repeat("d", 40)
+		e = strings.Repeat("e", 40)
+		f = strings.Repeat("f", 40)
+	)
+
+	// newest first: f reverts e which reverts d which reverts a, c reverts a
+	// commit outside of the range
+	changes := []change{
+		{Commit: "f", hash: f, body: "This reverts commit " + e + "."},
+		{Commit: "e", hash: e, body: "This reverts commit " + d[:12] + "."},
+		{Commit: "d", hash: d, body: "This reverts commit " + a + "."},
+		{Commit: "c", hash: c, body: "This reverts commit 0123456789."},
+		{Commit: "b", hash: b},
+		{Commit: "a", hash: a},
+	}
+
+	var commits []string
+	for _, c := range cancelReverts(changes) {
+		commits = append(commits, c.Commit)
+	}
+
+	// d cancels a, e reverts the cancelled d and is kept, f cancels e
+	if expected := []string{"c", "b"}; !reflect.DeepEqual(commits, expected) {
+		t.Errorf("unexpected changes %v, expected %v", commits, expected)
+	}
+}
+
+func TestDropShipped(t *testing.T) {
+	testRepo(t)
+	testCommit(t, "README.md", "hello\n", "initial commit")
+
+	head := func() string {
+		t.Helper()
+
+		o, err := git("rev-parse", "HEAD")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return strings.TrimSpace(string(o))
+	}
+
+	run := func(args ...string) {
+		t.Helper()
+
+		if _, err := git(args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run("branch", "release/1.0")
+
+	testCommit(t, "a.txt", "a\n", "fix: a")
+	a := head()
+	testCommit(t, "b.txt", "b\n", "fix: b")
+	b := head()
+	testCommit(t, "c.txt", "c\n", "feat: c")
+
+	run("checkout", "-q", "release/1.0")
+	testCommit(t, "a.txt", "a backported\n", "fix: a\n\n(cherry picked from commit "+a+")")
+	run("cherry-pick", b)
+	testCommit(t, "e.txt", "e\n", "fix: e")
+	e := head()
+	run("tag", "v1.0.1")
+
+	run("checkout", "-q", "main")
+	testCommit(t, "e.txt", "e forward ported\n", "fix: e\n\n(cherry picked from commit "+e+")")
+
+	changes, err := changelog("v1.0.1", "main", defaultCommitParser, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 4 {
+		t.Fatalf("expected 4 changes in range, got %d", len(changes))
+	}
+
+	kept, err := dropShipped("v1.0.1", "main", changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(kept) != 1 || kept[0].Description != "feat: c" {
+		t.Errorf("unexpected changes %+v", kept)
+	}
+
+	kept, err = dropShipped("", "main", changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(kept) != len(changes) {
+		t.Errorf("expected no changes to be dropped without previous, got %d", len(kept))
+	}
+}
@@ -0,0 +1,143 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type changelogOptions struct {
+	// Include and Exclude are patterns matching the subject of commits,
+	// when Include is set only matching commits are kept.
+	Include []string `toml:"include" json:"include" yaml:"include"`
+	Exclude []string `toml:"exclude" json:"exclude" yaml:"exclude"`
+	// IncludeAuthors and ExcludeAuthors are patterns matching the name or
+	// email of the author of commits.
+	IncludeAuthors []string `toml:"include_authors" json:"include_authors" yaml:"include_authors"`
+	ExcludeAuthors []string `toml:"exclude_authors" json:"exclude_authors" yaml:"exclude_authors"`
+	// Paths are git pathspecs, such as `cmd/` or `*.go`, only commits
+	// touching a matching file are kept.
+	Paths []string `toml:"paths" json:"paths" yaml:"paths"`
+}
+
+// changelogFilter selects the commits of the changelogs of the project and
+// its matched dependencies.
+type changelogFilter struct {
+	include        []*regexp.Regexp
+	exclude        []*regexp.Regexp
+	includeAuthors []*regexp.Regexp
+	excludeAuthors []*regexp.Regexp
+	paths          []string
+}
+
+func newChangelogFilter(opts changelogOptions) (*changelogFilter, error) {
+	f := &changelogFilter{
+		paths: opts.Paths,
+	}
+
+	for _, p := range []struct {
+		name     string
+		patterns []string
+		res      *[]*regexp.Regexp
+	}{
+		{"include", opts.Include, &f.include},
+		{"exclude", opts.Exclude, &f.exclude},
+		{"include_authors", opts.IncludeAuthors, &f.includeAuthors},
+		{"exclude_authors", opts.ExcludeAuthors, &f.excludeAuthors},
+	} {
+		for _, pattern := range p.patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid changelog %s pattern: %w", p.name, err)
+			}
+
+			*p.res = append(*p.res, re)
+		}
+	}
+
+	return f, nil
+}
+
+// includes reports whether the change is kept in the changelog.
+func (f *changelogFilter) includes(c change) bool {
+	if len(f.include) > 0 && !matchAny(f.include, c.Description) {
+		return false
+	}
+
+	if matchAny(f.exclude, c.Description) {
+		return false
+	}
+
+	if len(f.includeAuthors) > 0 && !matchAny(f.includeAuthors, c.authorName, c.authorEmail) {
+		return false
+	}
+
+	return !matchAny(f.excludeAuthors, c.authorName, c.authorEmail)
+}
+
+// filterChanges returns the changes kept in the changelog, the paths are
+// applied when the changelog is read.
+func (f *changelogFilter) filterChanges(changes []change) []change {
+	filtered := make([]change, 0, len(changes))
+
+	for _, c := range changes {
+		if f.includes(c) {
+			filtered = append(filtered, c)
+		}
+	}
+
+	return filtered
+}
+
+// touchingPaths returns the changes touching a file matched by paths
+// between previous and commit. The pathspecs of a git log match any of
+// them, the changes of two sets of paths are intersected with it.
+func touchingPaths(previous, commit string, paths []string, changes []change) ([]change, error) {
+	raw, err := git(append([]string{"log", "--format=%H", gitChangeDiff(previous, commit), "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	touched := map[string]struct{}{}
+	for _, hash := range strings.Fields(string(raw)) {
+		touched[hash] = struct{}{}
+	}
+
+	kept := make([]change, 0, len(changes))
+
+	for _, c := range changes {
+		if _, ok := touched[c.hash]; ok {
+			kept = append(kept, c)
+		}
+	}
+
+	return kept, nil
+}
+
+func matchAny(res []*regexp.Regexp, values ...string) bool {
+	for _, re := range res {
+		for _, v := range values {
+			if re.MatchString(v) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
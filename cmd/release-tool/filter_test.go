@@ -0,0 +1,101 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChangelogFilter(t *testing.T) {
+	testRepo(t)
+	testCommit(t, "README.md", "hello\n", "initial commit")
+
+	if _, err := git("tag", "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	testCommit(t, "cmd/tool/main.go", "package main\n", "feat: add tool")
+	testCommit(t, "cmd/tool/main.go", "package main\n\n", "fixup! feat: add tool")
+	testCommit(t, ".github/workflows/ci.yml", "on: push\n", "chore(ci): add workflow")
+	testCommit(t, "docs/tool.md", "# tool\n", "docs: document tool")
+
+	if _, err := git("-c", "user.name=Other", "-c", "user.email=other@example.com", "commit", "-q", "--allow-empty", "-m", "feat: empty"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		opts     changelogOptions
+		expected []string
+	}{
+		{
+			name:     "All",
+			expected: []string{"feat: empty", "docs: document tool", "chore(ci): add workflow", "fixup! feat: add tool", "feat: add tool"},
+		},
+		{
+			name:     "Exclude",
+			opts:     changelogOptions{Exclude: []string{`^chore\(ci\):`, `^(fixup|squash)! `}},
+			expected: []string{"feat: empty", "docs: document tool", "feat: add tool"},
+		},
+		{
+			name:     "Include",
+			opts:     changelogOptions{Include: []string{`^feat`}, Exclude: []string{`^fixup! `}},
+			expected: []string{"feat: empty", "feat: add tool"},
+		},
+		{
+			name:     "Authors",
+			opts:     changelogOptions{ExcludeAuthors: []string{`^other@`}},
+			expected: []string{"docs: document tool", "chore(ci): add workflow", "fixup! feat: add tool", "feat: add tool"},
+		},
+		{
+			name:     "IncludeAuthors",
+			opts:     changelogOptions{IncludeAuthors: []string{`^Other$`}},
+			expected: []string{"feat: empty"},
+		},
+		{
+			name:     "Paths",
+			opts:     changelogOptions{Paths: []string{"cmd/", "*.md"}},
+			expected: []string{"docs: document tool", "fixup! feat: add tool", "feat: add tool"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := newChangelogFilter(tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			changes, err := changelog("v1.0.0", "HEAD", defaultCommitParser, f.paths)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var descriptions []string
+			for _, c := range f.filterChanges(changes) {
+				descriptions = append(descriptions, c.Description)
+			}
+
+			if !reflect.DeepEqual(descriptions, tc.expected) {
+				t.Errorf("unexpected changes %q, expected %q", descriptions, tc.expected)
+			}
+		})
+	}
+
+	if _, err := newChangelogFilter(changelogOptions{Exclude: []string{"("}}); err == nil {
+		t.Error("expected invalid pattern to fail")
+	}
+}
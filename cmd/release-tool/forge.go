@@ -0,0 +1,445 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	forgeGitHub    = "github"
+	forgeGitLab    = "gitlab"
+	forgeGitea     = "gitea"
+	forgeForgejo   = "forgejo"
+	forgeBitbucket = "bitbucket"
+)
+
+// forgeHosts are the public instances of the forges, used when no
+// `forge_url` is set.
+var forgeHosts = map[string]string{
+	forgeGitHub:    "https://github.com",
+	forgeGitLab:    "https://gitlab.com",
+	forgeGitea:     "https://gitea.com",
+	forgeForgejo:   "https://codeberg.org",
+	forgeBitbucket: "https://bitbucket.org",
+}
+
+// forge builds links to the web interface of a git hosting service for a
+// repository.
+type forge interface {
+	kind() string
+	base() string
+	repo() string
+	repoURL() string
+	commitURL(sha string) string
+	pullRequestURL(number string) string
+	issueURL(number string) string
+	compareURL(from, to string) string
+	releaseURL(tag string) string
+	issuesURL() string
+
+	// pullRequestRef returns how a pull request is referred to in text,
+	// such as `#12` or `!12`.
+	pullRequestRef(number string) string
+
+	// pullRequest returns the number of the pull request merged by c, loc
+	// is the location of the reference in the description or nil if the
+	// description does not refer to it.
+	pullRequest(c change) (number string, loc []int)
+}
+
+// forgeRepo is the repository common to all forges.
+type forgeRepo struct {
+	baseURL string
+	path    string
+}
+
+func (f forgeRepo) base() string {
+	return f.baseURL
+}
+
+func (f forgeRepo) repo() string {
+	return f.path
+}
+
+func (f forgeRepo) repoURL() string {
+	return f.baseURL + "/" + f.path
+}
+
+type githubForge struct{ forgeRepo }
+
+var githubMerge = regexp.MustCompile(`^Merge pull request (#([0-9]+))`)
+
+func (githubForge) kind() string { return forgeGitHub }
+
+func (f githubForge) commitURL(sha string) string {
+	return f.repoURL() + "/commit/" + sha
+}
+
+func (f githubForge) pullRequestURL(number string) string {
+	return f.repoURL() + "/pull/" + number
+}
+
+func (f githubForge) compareURL(from, to string) string {
+	return f.repoURL() + "/compare/" + from + "..." + to
+}
+
+func (f githubForge) releaseURL(tag string) string {
+	return f.repoURL() + "/releases/tag/" + tag
+}
+
+func (f githubForge) issueURL(number string) string {
+	return f.repoURL() + "/issues/" + number
+}
+
+func (f githubForge) issuesURL() string {
+	return f.repoURL() + "/issues"
+}
+
+func (githubForge) pullRequestRef(number string) string {
+	return "#" + number
+}
+
+func (githubForge) pullRequest(c change) (string, []int) {
+	return matchPullRequest(githubMerge, c.Description)
+}
+
+type gitlabForge struct{ forgeRepo }
+
+// gitlabMerge matches the merge request trailer of GitLab merge commits.
+var gitlabMerge = regexp.MustCompile(`(?m)^See merge request [^\s!]*!([0-9]+)`)
+
+func (gitlabForge) kind() string { return forgeGitLab }
+
+func (f gitlabForge) commitURL(sha string) string {
+	return f.repoURL() + "/-/commit/" + sha
+}
+
+func (f gitlabForge) pullRequestURL(number string) string {
+	return f.repoURL() + "/-/merge_requests/" + number
+}
+
+func (f gitlabForge) compareURL(from, to string) string {
+	return f.repoURL() + "/-/compare/" + from + "..." + to
+}
+
+func (f gitlabForge) releaseURL(tag string) string {
+	return f.repoURL() + "/-/releases/" + tag
+}
+
+func (f gitlabForge) issueURL(number string) string {
+	return f.repoURL() + "/-/issues/" + number
+}
+
+func (f gitlabForge) issuesURL() string {
+	return f.repoURL() + "/-/issues"
+}
+
+func (gitlabForge) pullRequestRef(number string) string {
+	return "!" + number
+}
+
+func (gitlabForge) pullRequest(c change) (string, []int) {
+	if m := gitlabMerge.FindStringSubmatch(c.body); m != nil {
+		return m[1], nil
+	}
+
+	return "", nil
+}
+
+// giteaForge is used for both Gitea and Forgejo, which share the same
+// web interface.
+type giteaForge struct {
+	forgeRepo
+
+	name string
+}
+
+var giteaMerge = regexp.MustCompile(`^Merge pull request .*?(#([0-9]+))`)
+
+func (f giteaForge) kind() string { return f.name }
+
+func (f giteaForge) commitURL(sha string) string {
+	return f.repoURL() + "/commit/" + sha
+}
+
+func (f giteaForge) pullRequestURL(number string) string {
+	return f.repoURL() + "/pulls/" + number
+}
+
+func (f giteaForge) compareURL(from, to string) string {
+	return f.repoURL() + "/compare/" + from + "..." + to
+}
+
+func (f giteaForge) releaseURL(tag string) string {
+	return f.repoURL() + "/releases/tag/" + tag
+}
+
+func (f giteaForge) issueURL(number string) string {
+	return f.repoURL() + "/issues/" + number
+}
+
+func (f giteaForge) issuesURL() string {
+	return f.repoURL() + "/issues"
+}
+
+func (giteaForge) pullRequestRef(number string) string {
+	return "#" + number
+}
+
+func (giteaForge) pullRequest(c change) (string, []int) {
+	return matchPullRequest(giteaMerge, c.Description)
+}
+
+type bitbucketForge struct{ forgeRepo }
+
+var bitbucketMerge = regexp.MustCompile(`^Merged in .*\(pull request (#([0-9]+))\)`)
+
+func (bitbucketForge) kind() string { return forgeBitbucket }
+
+func (f bitbucketForge) commitURL(sha string) string {
+	return f.repoURL() + "/commits/" + sha
+}
+
+func (f bitbucketForge) pullRequestURL(number string) string {
+	return f.repoURL() + "/pull-requests/" + number
+}
+
+// compareURL uses the branch comparison of Bitbucket, which takes the
+// newer revision first.
+func (f bitbucketForge) compareURL(from, to string) string {
+	return f.repoURL() + "/branches/compare/" + url.PathEscape(to) + "%0D" + url.PathEscape(from)
+}
+
+// releaseURL links to the source at the tag, Bitbucket has no releases.
+func (f bitbucketForge) releaseURL(tag string) string {
+	return f.repoURL() + "/src/" + tag
+}
+
+func (f bitbucketForge) issueURL(number string) string {
+	return f.repoURL() + "/issues/" + number
+}
+
+func (f bitbucketForge) issuesURL() string {
+	return f.repoURL() + "/issues"
+}
+
+func (bitbucketForge) pullRequestRef(number string) string {
+	return "#" + number
+}
+
+func (bitbucketForge) pullRequest(c change) (string, []int) {
+	return matchPullRequest(bitbucketMerge, c.Description)
+}
+
+// matchPullRequest matches re against s, the first group of re is the
+// reference to the pull request and the second group its number.
+func matchPullRequest(re *regexp.Regexp, s string) (string, []int) {
+	m := re.FindStringSubmatchIndex(s)
+	if m == nil {
+		return "", nil
+	}
+
+	return s[m[4]:m[5]], m[2:4]
+}
+
+// newForge returns the forge of kind for the repository at path, baseURL
+// defaults to the public instance of the forge.
+func newForge(kind, baseURL, path string) (forge, error) {
+	kind = strings.ToLower(kind)
+
+	if baseURL == "" {
+		baseURL = forgeHosts[kind]
+	}
+
+	repo := forgeRepo{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		path:    strings.Trim(path, "/"),
+	}
+
+	switch kind {
+	case forgeGitHub:
+		return githubForge{repo}, nil
+	case forgeGitLab:
+		return gitlabForge{repo}, nil
+	case forgeGitea, forgeForgejo:
+		return giteaForge{forgeRepo: repo, name: kind}, nil
+	case forgeBitbucket:
+		return bitbucketForge{repo}, nil
+	default:
+		return nil, fmt.Errorf("unknown forge %q, must be one of github, gitlab, gitea, forgejo or bitbucket", kind)
+	}
+}
+
+// scpURL matches scp-like git URLs such as `git@github.com:owner/repo.git`.
+var scpURL = regexp.MustCompile(`^(?:[\w.-]+@)?([\w.-]+):([^/].*)$`)
+
+// parseForgeURL infers the forge, its base URL and the repository path from
+// a git remote URL or module path. Only hosts which name a known forge are
+// recognized.
+func parseForgeURL(remote string) (kind, baseURL, path string, ok bool) {
+	var host string
+
+	if m := scpURL.FindStringSubmatch(remote); m != nil && !strings.Contains(remote, "://") {
+		host, path = m[1], m[2]
+	} else {
+		if !strings.Contains(remote, "://") {
+			remote = "https://" + remote
+		}
+
+		u, err := url.Parse(remote)
+		if err != nil || u.Hostname() == "" {
+			return "", "", "", false
+		}
+
+		host, path = u.Hostname(), u.Path
+	}
+
+	host = strings.ToLower(host)
+	kind = forgeKindForHost(host)
+
+	if kind == "" {
+		return "", "", "", false
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 {
+		return "", "", "", false
+	}
+
+	// only GitLab supports nested groups
+	if kind != forgeGitLab {
+		path = strings.Join(parts[:2], "/")
+	}
+
+	return kind, "https://" + host, path, true
+}
+
+// forgeKindForHost guesses the forge from its host name.
+func forgeKindForHost(host string) string {
+	switch {
+	case strings.Contains(host, "github"):
+		return forgeGitHub
+	case strings.Contains(host, "gitlab"):
+		return forgeGitLab
+	case strings.Contains(host, "bitbucket"):
+		return forgeBitbucket
+	case strings.Contains(host, "codeberg"), strings.Contains(host, "forgejo"):
+		return forgeForgejo
+	case strings.Contains(host, "gitea"):
+		return forgeGitea
+	default:
+		return ""
+	}
+}
+
+// releaseForge returns the forge of the project, the `forge` and
+// `forge_url` keys take precedence over the forge inferred from the origin
+// remote. Without any of them, `github_repo` is a GitHub repository. No
+// forge is returned when the repository is unknown.
+func releaseForge(r *release) (forge, error) {
+	var (
+		kind    = r.Forge
+		baseURL = r.ForgeURL
+		path    = r.GithubRepo
+	)
+
+	// github_repo without forge keeps referring to GitHub whatever the
+	// origin remote is
+	if kind == "" && path != "" {
+		kind = forgeGitHub
+	}
+
+	if path == "" {
+		if remote, err := git("remote", "get-url", "origin"); err == nil {
+			if k, b, p, ok := parseForgeURL(strings.TrimSpace(string(remote))); ok && (kind == "" || strings.EqualFold(kind, k)) {
+				kind = k
+
+				if baseURL == "" {
+					baseURL = b
+				}
+
+				if path == "" {
+					path = p
+				}
+			}
+		}
+	}
+
+	if path == "" {
+		return nil, nil //nolint: nilnil
+	}
+
+	return newForge(kind, baseURL, path)
+}
+
+// dependencyForge returns the forge hosting dep, dependencies on the host
+// of the project forge use the same kind of forge.
+func dependencyForge(dep dependency, project forge) forge {
+	remote := dep.GitURL
+	if remote == "" {
+		remote = dep.Name
+	}
+
+	kind, baseURL, path, ok := parseForgeURL(remote)
+	if !ok {
+		if project == nil {
+			return nil
+		}
+
+		u, err := url.Parse(project.repoURL())
+		if err != nil || !strings.HasPrefix(strings.TrimPrefix(remote, "https://"), u.Host+"/") {
+			return nil
+		}
+
+		kind = project.kind()
+		baseURL = "https://" + u.Host
+		path = strings.TrimPrefix(strings.TrimPrefix(remote, "https://"), u.Host+"/")
+		path = strings.TrimSuffix(path, ".git")
+	}
+
+	f, err := newForge(kind, baseURL, path)
+	if err != nil {
+		return nil
+	}
+
+	return f
+}
+
+// forgeCommitLink returns a function which links the commit of a change,
+// with gfm GitHub commits are referenced as `owner/repo@sha` to be
+// rendered by GitHub.
+func forgeCommitLink(f forge, gfm bool) func(change) (string, error) {
+	return func(c change) (string, error) {
+		if gfm && f.kind() == forgeGitHub {
+			return fmt.Sprintf("%s@%s", f.repo(), c.Commit), nil
+		}
+
+		link := f.commitURL(c.hash)
+
+		if gfm {
+			return fmt.Sprintf("[`%s`](%s)", c.Commit, link), nil
+		}
+
+		return link, nil
+	}
+}
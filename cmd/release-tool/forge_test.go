@@ -0,0 +1,201 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestParseForgeURL(t *testing.T) {
+	for _, tc := range []struct {
+		remote  string
+		kind    string
+		baseURL string
+		path    string
+	}{
+		{remote: "https://github.com/containerd/containerd.git", kind: forgeGitHub, baseURL: "https://github.com", path: "containerd/containerd"},
+		{remote: "git@github.com:containerd/release-tool.git", kind: forgeGitHub, baseURL: "https://github.com", path: "containerd/release-tool"},
+		{remote: "github.com/containerd/containerd/api", kind: forgeGitHub, baseURL: "https://github.com", path: "containerd/containerd"},
+		{remote: "ssh://git@gitlab.example.com:2222/group/sub/project.git", kind: forgeGitLab, baseURL: "https://gitlab.example.com", path: "group/sub/project"},
+		{remote: "https://codeberg.org/forgejo/forgejo", kind: forgeForgejo, baseURL: "https://codeberg.org", path: "forgejo/forgejo"},
+		{remote: "https://gitea.com/gitea/tea", kind: forgeGitea, baseURL: "https://gitea.com", path: "gitea/tea"},
+		{remote: "git@bitbucket.org:owner/repo.git", kind: forgeBitbucket, baseURL: "https://bitbucket.org", path: "owner/repo"},
+		{remote: "https://go.googlesource.com/mod"},
+		{remote: "https://github.com/containerd"},
+	} {
+		kind, baseURL, path, ok := parseForgeURL(tc.remote)
+		if ok != (tc.kind != "") || kind != tc.kind || baseURL != tc.baseURL || path != tc.path {
+			t.Errorf("unexpected forge for %s: %q %q %q %t", tc.remote, kind, baseURL, path, ok)
+		}
+	}
+}
+
+func TestForgeLinks(t *testing.T) {
+	for _, tc := range []struct {
+		kind        string
+		change      change
+		commit      string
+		compare     string
+		description string
+	}{
+		{
+			kind:        forgeGitHub,
+			change:      change{Description: "Merge pull request #12 from user/branch"},
+			commit:      "https://github.com/owner/repo/commit/abc",
+			compare:     "https://github.com/owner/repo/compare/v1.0.0...v1.1.0",
+			description: "Merge pull request [#12](https://github.com/owner/repo/pull/12) from user/branch",
+		},
+		{
+			kind:        forgeGitLab,
+			change:      change{Description: "Merge branch 'fix' into 'main'", body: "Fix it\n\nSee merge request owner/repo!34"},
+			commit:      "https://gitlab.com/owner/repo/-/commit/abc",
+			compare:     "https://gitlab.com/owner/repo/-/compare/v1.0.0...v1.1.0",
+			description: "Merge branch 'fix' into 'main' [!34](https://gitlab.com/owner/repo/-/merge_requests/34)",
+		},
+		{
+			kind:        forgeForgejo,
+			change:      change{Description: "Merge pull request 'Fix it' (#56) from fix into main"},
+			commit:      "https://codeberg.org/owner/repo/commit/abc",
+			compare:     "https://codeberg.org/owner/repo/compare/v1.0.0...v1.1.0",
+			description: "Merge pull request 'Fix it' ([#56](https://codeberg.org/owner/repo/pulls/56)) from fix into main",
+		},
+		{
+			kind:        forgeBitbucket,
+			change:      change{Description: "Merged in fix (pull request #78)"},
+			commit:      "https://bitbucket.org/owner/repo/commits/abc",
+			compare:     "https://bitbucket.org/owner/repo/branches/compare/v1.1.0%0Dv1.0.0",
+			description: "Merged in fix (pull request [#78](https://bitbucket.org/owner/repo/pull-requests/78))",
+		},
+	} {
+		f, err := newForge(tc.kind, "", "owner/repo")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if commit := f.commitURL("abc"); commit != tc.commit {
+			t.Errorf("[%s] unexpected commit URL %s", tc.kind, commit)
+		}
+
+		// the full hash of the change is linked without running git
+		if link, err := forgeCommitLink(f, false)(change{Commit: "ab", hash: "abc"}); err != nil || link != tc.commit {
+			t.Errorf("[%s] unexpected commit link %s: %v", tc.kind, link, err)
+		}
+
+		if compare := f.compareURL("v1.0.0", "v1.1.0"); compare != tc.compare {
+			t.Errorf("[%s] unexpected compare URL %s", tc.kind, compare)
+		}
+
+		description, err := referenceLinks(f, nil)(tc.change)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if description != tc.description {
+			t.Errorf("[%s] unexpected description %q", tc.kind, description)
+		}
+	}
+
+	if _, err := newForge("sourcehut", "", "owner/repo"); err == nil {
+		t.Error("expected error for unknown forge")
+	}
+}
+
+func TestReferenceLinks(t *testing.T) {
+	r := &release{
+		Trackers: map[string]tracker{
+			"jira": {Pattern: `\b([A-Z]+)-([0-9]+)\b`, URL: "https://jira.example.com/browse/$1-$2"},
+		},
+	}
+
+	trackers, err := r.orderedTrackers()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		kind        string
+		change      change
+		description string
+	}{
+		{
+			kind:        forgeGitHub,
+			change:      change{Description: "fix: handle nil values (#1234)"},
+			description: "fix: handle nil values ([#1234](https://github.com/owner/repo/pull/1234))",
+		},
+		{
+			kind:        forgeGitHub,
+			change:      change{Description: "Merge pull request #12 from user/branch"},
+			description: "Merge pull request [#12](https://github.com/owner/repo/pull/12) from user/branch",
+		},
+		{
+			kind:        forgeGitHub,
+			change:      change{Description: "Revert containerd/containerd#56 for PROJ-78 (#90)", body: "Fixes #11\nCloses other/repo#22\nfixes #90"},
+			description: "Revert [containerd/containerd#56](https://github.com/containerd/containerd/issues/56) for [PROJ-78](https://jira.example.com/browse/PROJ-78) ([#90](https://github.com/owner/repo/pull/90)) (fixes [#11](https://github.com/owner/repo/issues/11), [other/repo#22](https://github.com/other/repo/issues/22))",
+		},
+		{
+			kind:        forgeGitLab,
+			change:      change{Description: "feat: add option (!34), see group/project!5"},
+			description: "feat: add option (!34), see [group/project!5](https://gitlab.com/group/project/-/merge_requests/5)",
+		},
+		{
+			kind:        forgeGitLab,
+			change:      change{Description: "feat: add option (!34)"},
+			description: "feat: add option ([!34](https://gitlab.com/owner/repo/-/merge_requests/34))",
+		},
+	} {
+		f, err := newForge(tc.kind, "", "owner/repo")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		description, err := referenceLinks(f, trackers)(tc.change)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if description != tc.description {
+			t.Errorf("[%s] unexpected description\n%s\nexpected\n%s", tc.kind, description, tc.description)
+		}
+	}
+}
+
+func TestReleaseForge(t *testing.T) {
+	testRepo(t)
+
+	if _, err := git("remote", "add", "origin", "https://gitlab.example.com/mirror/repo.git"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		r    release
+		repo string
+	}{
+		{release{}, "https://gitlab.example.com/mirror/repo"},
+		// github_repo without forge is on GitHub whatever the origin is
+		{release{GithubRepo: "containerd/release-tool"}, "https://github.com/containerd/release-tool"},
+		{release{Forge: forgeGitLab, GithubRepo: "group/project"}, "https://gitlab.com/group/project"},
+		{release{Forge: forgeGitLab, ForgeURL: "https://gitlab.internal", GithubRepo: "group/project"}, "https://gitlab.internal/group/project"},
+		{release{Forge: forgeGitLab}, "https://gitlab.example.com/mirror/repo"},
+	} {
+		f, err := releaseForge(&tc.r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if f == nil || f.repoURL() != tc.repo {
+			t.Errorf("%+v: unexpected forge %v", tc.r, f)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultGithubAPI = "https://api.github.com"
+
+var errNotFound = errors.New("not found")
+
+// githubClient is a minimal client for the GitHub REST API.
+type githubClient struct {
+	client *http.Client
+	apiURL string
+	token  string
+}
+
+func newGithubClient(apiURL, token string) *githubClient {
+	if apiURL == "" {
+		apiURL = defaultGithubAPI
+	}
+
+	return &githubClient{
+		client: http.DefaultClient,
+		apiURL: strings.TrimRight(apiURL, "/"),
+		token:  token,
+	}
+}
+
+type githubRelease struct { //nolint: govet
+	ID         int64  `json:"id,omitempty"`
+	TagName    string `json:"tag_name"`
+	Target     string `json:"target_commitish,omitempty"`
+	Name       string `json:"name"`
+	Body       string `json:"body"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+	HTMLURL    string `json:"html_url,omitempty"`
+}
+
+// linkNext matches the URL of the next page in a Link header.
+var linkNext = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// do performs an API request, in and out are JSON encoded when not nil.
+// A 404 response is returned as errNotFound.
+func (c *githubClient) do(ctx context.Context, method, path string, in, out any) error {
+	_, err := c.request(ctx, method, path, in, out)
+
+	return err
+}
+
+// request performs an API request like do and returns the path of the
+// next page of a paginated response, empty for the last page.
+func (c *githubClient) request(ctx context.Context, method, path string, in, out any) (string, error) {
+	var body io.Reader
+
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return "", err
+		}
+
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL+path, body)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close() //nolint: errcheck
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%s %s: %w", method, path, errNotFound)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096)) //nolint: errcheck
+
+		return "", fmt.Errorf("unexpected status code %d for %s %s: %s", resp.StatusCode, method, path, bytes.TrimSpace(msg))
+	}
+
+	var next string
+
+	// only pages of the same API are followed
+	if m := linkNext.FindStringSubmatch(resp.Header.Get("Link")); m != nil && strings.HasPrefix(m[1], c.apiURL+"/") {
+		next = strings.TrimPrefix(m[1], c.apiURL)
+	}
+
+	if out == nil {
+		return next, nil
+	}
+
+	return next, json.NewDecoder(resp.Body).Decode(out)
+}
+
+// releaseByTag finds the release for tag, including draft releases which
+// are not returned by the tag lookup endpoint. It returns nil when no
+// release exists for tag.
+func (c *githubClient) releaseByTag(ctx context.Context, repo, tag string) (*githubRelease, error) {
+	var rel githubRelease
+
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/releases/tags/%s", repo, tag), nil, &rel)
+	if err == nil {
+		return &rel, nil
+	}
+
+	if !errors.Is(err, errNotFound) {
+		return nil, err
+	}
+
+	for path := fmt.Sprintf("/repos/%s/releases?per_page=100", repo); path != ""; {
+		var releases []githubRelease
+
+		if path, err = c.request(ctx, http.MethodGet, path, nil, &releases); err != nil {
+			return nil, err
+		}
+
+		for i := range releases {
+			if releases[i].TagName == tag {
+				return &releases[i], nil
+			}
+		}
+	}
+
+	return nil, nil //nolint: nilnil
+}
+
+// publishRelease creates the release for rel.TagName or updates it if it already exists.
+func (c *githubClient) publishRelease(ctx context.Context, repo string, rel githubRelease) (*githubRelease, error) {
+	existing, err := c.releaseByTag(ctx, repo, rel.TagName)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		out    githubRelease
+		method = http.MethodPost
+		path   = fmt.Sprintf("/repos/%s/releases", repo)
+	)
+
+	if existing != nil {
+		method = http.MethodPatch
+		path = fmt.Sprintf("/repos/%s/releases/%d", repo, existing.ID)
+	}
+
+	if err = c.do(ctx, method, path, rel, &out); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+type githubPullRequest struct { //nolint: govet
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	HTMLURL string `json:"html_url"`
+	User    struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	MergeCommitSHA string  `json:"merge_commit_sha"`
+	MergedAt       *string `json:"merged_at"`
+}
+
+// pullRequest fetches pull request number of repo, it returns nil when
+// the pull request does not exist or is not merged. Merged pull requests
+// are cached.
+func (c *githubClient) pullRequest(ctx context.Context, repo, number string, cache Cache) (*pullRequest, error) {
+	var (
+		pr  githubPullRequest
+		key = fmt.Sprintf("GET %s/repos/%s/pulls/%s", c.apiURL, repo, number)
+	)
+
+	if b, ok := cache.Get(key); ok && json.Unmarshal(b, &pr) == nil {
+		logrus.WithField("cache", "hit").Debug(key)
+	} else {
+		err := c.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls/%s", repo, number), nil, &pr)
+		if errors.Is(err, errNotFound) {
+			return nil, nil //nolint: nilnil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if pr.MergedAt != nil {
+			if b, err := json.Marshal(pr); err == nil {
+				cache.Put(key, b) //nolint: errcheck
+			}
+		}
+	}
+
+	if pr.MergedAt == nil {
+		return nil, nil //nolint: nilnil
+	}
+
+	labels := make([]string, 0, len(pr.Labels))
+	for _, l := range pr.Labels {
+		labels = append(labels, l.Name)
+	}
+
+	return &pullRequest{
+		Number:      pr.Number,
+		Title:       pr.Title,
+		Author:      pr.User.Login,
+		Labels:      labels,
+		MergeCommit: pr.MergeCommitSHA,
+		URL:         pr.HTMLURL,
+	}, nil
+}
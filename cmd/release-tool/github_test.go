@@ -0,0 +1,308 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeGithub is an in-memory implementation of the GitHub releases API.
+type fakeGithub struct {
+	releases map[int64]githubRelease
+	pulls    map[string]githubPullRequest
+	requests int
+	nextID   int64
+	// pageSize overrides the per_page of listed releases when set
+	pageSize int
+	mu       sync.Mutex
+}
+
+func (f *fakeGithub) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if req.Header.Get("Authorization") != "Bearer secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	f.requests++
+
+	if number, ok := strings.CutPrefix(req.URL.Path, "/repos/owner/repo/pulls/"); ok {
+		if pr, ok := f.pulls[number]; ok && req.Method == http.MethodGet {
+			json.NewEncoder(w).Encode(pr) //nolint: errcheck
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	const prefix = "/repos/owner/repo/releases"
+
+	path := strings.TrimPrefix(req.URL.Path, prefix)
+
+	switch {
+	case req.Method == http.MethodGet && strings.HasPrefix(path, "/tags/"):
+		for _, rel := range f.releases {
+			if !rel.Draft && rel.TagName == strings.TrimPrefix(path, "/tags/") {
+				json.NewEncoder(w).Encode(rel) //nolint: errcheck
+
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	case req.Method == http.MethodGet && path == "":
+		all := make([]githubRelease, 0, len(f.releases))
+		for _, rel := range f.releases {
+			all = append(all, rel)
+		}
+
+		// newest releases first like GitHub
+		sort.Slice(all, func(i, j int) bool { return all[i].ID > all[j].ID })
+
+		perPage, _ := strconv.Atoi(req.URL.Query().Get("per_page")) //nolint: errcheck
+		if f.pageSize > 0 {
+			perPage = f.pageSize
+		}
+
+		if perPage > 0 {
+			page, _ := strconv.Atoi(req.URL.Query().Get("page")) //nolint: errcheck
+			page = max(page, 1)
+
+			start := min((page-1)*perPage, len(all))
+			end := min(start+perPage, len(all))
+
+			if end < len(all) {
+				w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?per_page=%d&page=%d>; rel="next"`, req.Host, req.URL.Path, perPage, page+1))
+			}
+
+			all = all[start:end]
+		}
+
+		json.NewEncoder(w).Encode(all) //nolint: errcheck
+	case req.Method == http.MethodPost && path == "":
+		var rel githubRelease
+		if err := json.NewDecoder(req.Body).Decode(&rel); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		f.nextID++
+		rel.ID = f.nextID
+		rel.HTMLURL = fmt.Sprintf("https://github.com/owner/repo/releases/tag/%s", rel.TagName)
+		f.releases[rel.ID] = rel
+
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rel) //nolint: errcheck
+	case req.Method == http.MethodPatch:
+		var (
+			rel githubRelease
+			id  int64
+		)
+
+		if _, err := fmt.Sscanf(path, "/%d", &id); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		if err := json.NewDecoder(req.Body).Decode(&rel); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		rel.ID = id
+		f.releases[id] = rel
+
+		json.NewEncoder(w).Encode(rel) //nolint: errcheck
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestPublishRelease(t *testing.T) {
+	fake := &fakeGithub{releases: map[int64]githubRelease{}}
+
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	ctx := context.Background()
+	client := newGithubClient(srv.URL+"/", "secret")
+
+	rel, err := client.publishRelease(ctx, "owner/repo", githubRelease{
+		TagName:    "v1.0.0-rc.1",
+		Body:       "first",
+		Draft:      true,
+		Prerelease: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rel.ID != 1 || !rel.Draft || !rel.Prerelease {
+		t.Fatalf("unexpected created release %+v", rel)
+	}
+
+	// the draft is only found by listing releases
+	rel, err = client.publishRelease(ctx, "owner/repo", githubRelease{
+		TagName:    "v1.0.0-rc.1",
+		Body:       "second",
+		Prerelease: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rel.ID != 1 || rel.Draft || rel.Body != "second" {
+		t.Fatalf("unexpected updated release %+v", rel)
+	}
+
+	if len(fake.releases) != 1 {
+		t.Fatalf("expected 1 release, got %d", len(fake.releases))
+	}
+
+	if _, err = newGithubClient(srv.URL, "").publishRelease(ctx, "owner/repo", githubRelease{TagName: "v1.0.0"}); err == nil {
+		t.Fatal("expected error for unauthorized request")
+	}
+}
+
+func TestReleaseByTagPagination(t *testing.T) {
+	fake := &fakeGithub{releases: map[int64]githubRelease{}, pageSize: 2, nextID: 5}
+
+	for id := int64(1); id <= fake.nextID; id++ {
+		fake.releases[id] = githubRelease{ID: id, TagName: fmt.Sprintf("v1.0.%d", id), Draft: true}
+	}
+
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	client := newGithubClient(srv.URL, "secret")
+
+	// the oldest release is on the last page
+	rel, err := client.releaseByTag(context.Background(), "owner/repo", "v1.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rel == nil || rel.ID != 1 {
+		t.Fatalf("expected release 1, got %+v", rel)
+	}
+
+	if rel, err = client.releaseByTag(context.Background(), "owner/repo", "v2.0.0"); err != nil || rel != nil {
+		t.Fatalf("expected no release, got %+v, %v", rel, err)
+	}
+
+	if _, err = client.publishRelease(context.Background(), "owner/repo", githubRelease{TagName: "v1.0.1", Body: "updated"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fake.releases) != 5 || fake.releases[1].Body != "updated" {
+		t.Fatalf("expected release 1 to be updated, got %+v", fake.releases)
+	}
+}
+
+func TestAddPullRequests(t *testing.T) {
+	merged := "2024-01-02T03:04:05Z"
+
+	fix := githubPullRequest{Number: 12, Title: "Fix the thing", HTMLURL: "https://github.com/owner/repo/pull/12", MergeCommitSHA: "abc", MergedAt: &merged}
+	fix.User.Login = "octocat"
+	fix.Labels = append(fix.Labels, struct {
+		Name string `json:"name"`
+	}{Name: "kind/bug"})
+
+	fake := &fakeGithub{pulls: map[string]githubPullRequest{
+		"12": fix,
+		"34": {Number: 34, Title: "Add the thing", HTMLURL: "https://github.com/owner/repo/pull/34", MergeCommitSHA: "def", MergedAt: &merged},
+		"56": {Number: 56, Title: "Closed without merging"},
+	}}
+
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	f, err := newForge(forgeGitHub, "", "owner/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rc := &rangeCollector{
+		cache:        &dirCache{root: t.TempDir()},
+		github:       newGithubClient(srv.URL, "secret"),
+		pullRequests: map[string]*pullRequest{},
+	}
+
+	changes := []change{
+		{Description: "Merge pull request #12 from octocat/fix", hash: "abc"},
+		{Description: "feat: add the thing (#34)", hash: "def"},
+		{Description: "fix: refer to an issue (#78)"},
+		{Description: "chore: closed (#56)"},
+		{Description: "chore: no pull request"},
+		{Description: "Merge pull request #12 from octocat/fix", hash: "abc"},
+		// a cherry-pick is not the merge commit of the pull request
+		{Description: "fix: backport the fix (#12)", hash: "0123456"},
+		// neither is a later commit referring to the pull request
+		{Description: "fix: edge case missed in (#34)", hash: "fed"},
+	}
+
+	if err = rc.addPullRequests(context.Background(), f, changes); err != nil {
+		t.Fatal(err)
+	}
+
+	if pr := changes[0].PullRequest; pr == nil || pr.Title != "Fix the thing" || pr.Author != "octocat" || len(pr.Labels) != 1 || pr.Labels[0] != "kind/bug" || pr.MergeCommit != "abc" {
+		t.Errorf("unexpected pull request %+v", pr)
+	}
+
+	if pr := changes[1].PullRequest; pr == nil || pr.Number != 34 || pr.URL != "https://github.com/owner/repo/pull/34" {
+		t.Errorf("unexpected pull request %+v", pr)
+	}
+
+	for _, c := range append(append([]change(nil), changes[2:5]...), changes[6:]...) {
+		if c.PullRequest != nil {
+			t.Errorf("unexpected pull request for %q", c.Description)
+		}
+	}
+
+	if fake.requests != 4 {
+		t.Errorf("expected 4 requests, got %d", fake.requests)
+	}
+
+	// merged pull requests are cached
+	rc.pullRequests = map[string]*pullRequest{}
+
+	if err = rc.addPullRequests(context.Background(), f, changes[:2]); err != nil {
+		t.Fatal(err)
+	}
+
+	if fake.requests != 4 || changes[1].PullRequest == nil {
+		t.Errorf("expected pull requests from the cache, got %d requests", fake.requests)
+	}
+}
@@ -0,0 +1,348 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+var initCommand = &cli.Command{
+	Name:      "init",
+	Usage:     "generate the release file for the next release",
+	ArgsUsage: "<tag>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "release file to carry over the project options from, defaults to the output file when it exists or else to the release file of the previous release",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "file to write the release file to, defaults to stdout",
+		},
+		&cli.BoolFlag{
+			Name:    "force",
+			Aliases: []string{"f"},
+			Usage:   "replace an existing output file, its preface and notes are not kept",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		tag := context.Args().First()
+		if tag == "" {
+			return errors.New("please specify the tag of the new release as the first argument")
+		}
+
+		var (
+			from   = context.String("from")
+			output = context.String("output")
+			prior  = &release{}
+		)
+
+		previous, err := getPreviousTag(tag, "HEAD", true)
+		if err != nil {
+			return err
+		}
+
+		if previous == "" {
+			logrus.Warnf("unable to detect the release previous to %s", tag)
+		}
+
+		if from == "" {
+			from = priorReleaseFile(output, previous)
+		}
+
+		if output != "" && !context.Bool("force") {
+			if _, err := os.Stat(output); err == nil {
+				return fmt.Errorf("%s already exists, use --force to replace it", output)
+			}
+		}
+
+		if from != "" {
+			logrus.Debugf("carrying over the project options from %s", from)
+
+			if prior, err = loadRelease(from); err != nil {
+				return fmt.Errorf("failed to load prior release file: %w", err)
+			}
+		}
+
+		data := scaffoldRelease(prior, tag, previous)
+
+		if output == "" {
+			_, err = context.App.Writer.Write(data)
+
+			return err
+		}
+
+		if err = writeFileAtomic(output, data); err != nil {
+			return err
+		}
+
+		logrus.Infof("created release file %s for %s", output, tag)
+
+		return nil
+	},
+}
+
+// defaultReleasesDir is the directory of the release files, named after
+// their tag, when init writes to stdout.
+const defaultReleasesDir = "releases"
+
+// priorReleaseFile returns the release file to carry the project options
+// over from: the output file when it already exists and is replaced, or
+// else the release file of the previous release in the directory of the
+// output. It returns an empty path when neither exists.
+func priorReleaseFile(output, previous string) string {
+	if output != "" {
+		if _, err := os.Stat(output); err == nil {
+			return output
+		}
+	}
+
+	if previous == "" {
+		return ""
+	}
+
+	dir := defaultReleasesDir
+	if output != "" {
+		dir = filepath.Dir(output)
+	}
+
+	file := filepath.Join(dir, previous+".toml")
+	if _, err := os.Stat(file); err != nil {
+		return ""
+	}
+
+	return file
+}
+
+// preReleaseSuffix matches tags of alpha, beta and release candidate releases.
+var preReleaseSuffix = regexp.MustCompile(`-(alpha|beta|rc)([.\-]?[0-9]+)?($|[.\-+])`)
+
+func isPreRelease(tag string) bool {
+	return preReleaseSuffix.MatchString(strings.ToLower(tag))
+}
+
+// scaffoldRelease generates a release file for tag, the project options are
+// carried over from the prior release file.
+func scaffoldRelease(prior *release, tag, previous string) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# commit to be tagged for the new release\n")
+	fmt.Fprintf(&b, "commit = \"HEAD\"\n")
+
+	if prior.ProjectName != "" {
+		fmt.Fprintf(&b, "project_name = %s\n", tomlString(prior.ProjectName))
+	}
+
+	if prior.GithubRepo != "" {
+		fmt.Fprintf(&b, "github_repo = %s\n", tomlString(prior.GithubRepo))
+	}
+
+	if prior.Forge != "" {
+		fmt.Fprintf(&b, "forge = %s\n", tomlString(prior.Forge))
+	}
+
+	if prior.ForgeURL != "" {
+		fmt.Fprintf(&b, "forge_url = %s\n", tomlString(prior.ForgeURL))
+	}
+
+	if prior.MatchDeps != "" {
+		fmt.Fprintf(&b, "match_deps = %s\n", tomlString(prior.MatchDeps))
+	}
+
+	if len(prior.IgnoreDeps) > 0 {
+		fmt.Fprintf(&b, "ignore_deps = %s\n", tomlStrings(prior.IgnoreDeps))
+	}
+
+	if prior.ChglogConfig != "" {
+		fmt.Fprintf(&b, "chglog_config = %s\n", tomlString(prior.ChglogConfig))
+	}
+
+	if prior.Artifacts != "" {
+		fmt.Fprintf(&b, "artifacts = %s\n", tomlString(prior.Artifacts))
+	}
+
+	if prior.ArtifactsSHA512 {
+		fmt.Fprintf(&b, "artifacts_sha512 = true\n")
+	}
+
+	fmt.Fprintf(&b, "\n# previous release of this project for determining changes\n")
+
+	if previous != "" {
+		fmt.Fprintf(&b, "previous = %s\n", tomlString(previous))
+	} else {
+		fmt.Fprintf(&b, "# previous = \"\"\n")
+	}
+
+	fmt.Fprintf(&b, "pre_release = %t\n\n", isPreRelease(tag))
+
+	fmt.Fprintf(&b, "preface = \"\"\"\\\n\"\"\"\n\n")
+
+	fmt.Fprintf(&b, "[notes]\n\n")
+	fmt.Fprintf(&b, "# [notes.highlight]\n# title = \"\"\n# description = \"\"\"\\\n# \"\"\"\n")
+
+	for _, name := range orderedKeys(prior.meta, "trackers", prior.Trackers) {
+		t := prior.Trackers[name]
+
+		fmt.Fprintf(&b, "\n[trackers.%s]\n", tomlKey(name))
+		fmt.Fprintf(&b, "pattern = %s\n", tomlString(t.Pattern))
+		fmt.Fprintf(&b, "url = %s\n", tomlString(t.URL))
+	}
+
+	if aliases := prior.ContributorOptions.Aliases; len(aliases) > 0 {
+		logins := make([]string, 0, len(aliases))
+		for login := range aliases {
+			logins = append(logins, login)
+		}
+
+		sort.Strings(logins)
+
+		fmt.Fprintf(&b, "\n[contributors.aliases]\n")
+
+		for _, login := range logins {
+			fmt.Fprintf(&b, "%s = %s\n", tomlKey(login), tomlStrings(aliases[login]))
+		}
+	}
+
+	if cl := prior.Changelog; len(cl.Include)+len(cl.Exclude)+len(cl.IncludeAuthors)+len(cl.ExcludeAuthors)+len(cl.Paths) > 0 {
+		fmt.Fprintf(&b, "\n[changelog]\n")
+
+		for _, option := range []struct {
+			key    string
+			values []string
+		}{
+			{"include", cl.Include},
+			{"exclude", cl.Exclude},
+			{"include_authors", cl.IncludeAuthors},
+			{"exclude_authors", cl.ExcludeAuthors},
+			{"paths", cl.Paths},
+		} {
+			if len(option.values) > 0 {
+				fmt.Fprintf(&b, "%s = %s\n", option.key, tomlStrings(option.values))
+			}
+		}
+	}
+
+	if bots := prior.Bots; bots.Authors != nil || bots.Subjects != nil || bots.Commits != "" {
+		fmt.Fprintf(&b, "\n[bots]\n")
+
+		if bots.Authors != nil {
+			fmt.Fprintf(&b, "authors = %s\n", tomlStrings(bots.Authors))
+		}
+
+		if bots.Subjects != nil {
+			fmt.Fprintf(&b, "subjects = %s\n", tomlStrings(bots.Subjects))
+		}
+
+		if bots.Commits != "" {
+			fmt.Fprintf(&b, "commits = %s\n", tomlString(bots.Commits))
+		}
+	}
+
+	for _, c := range prior.orderedComponents() {
+		fmt.Fprintf(&b, "\n[components.%s]\n", tomlKey(c.Name))
+		fmt.Fprintf(&b, "paths = %s\n", tomlStrings(c.Paths))
+	}
+
+	for _, rename := range prior.orderedRenameDeps() {
+		fmt.Fprintf(&b, "\n[rename_deps.%s]\n", tomlKey(rename.Name))
+		fmt.Fprintf(&b, "old = %s\n", tomlString(rename.Old))
+		fmt.Fprintf(&b, "new = %s\n", tomlString(rename.New))
+	}
+
+	for _, name := range orderedKeys(prior.meta, "make_deps", prior.MakeDeps) {
+		makeDep := prior.MakeDeps[name]
+
+		fmt.Fprintf(&b, "\n[make_deps.%s]\n", tomlKey(name))
+		fmt.Fprintf(&b, "variable = %s\n", tomlString(makeDep.Variable))
+		fmt.Fprintf(&b, "repository = %s\n", tomlString(makeDep.Repository))
+	}
+
+	for _, group := range prior.ChangeGroups {
+		fmt.Fprintf(&b, "\n[[change_groups]]\n")
+		fmt.Fprintf(&b, "title = %s\n", tomlString(group.Title))
+		fmt.Fprintf(&b, "types = %s\n", tomlStrings(group.Types))
+	}
+
+	return []byte(b.String())
+}
+
+// tomlString quotes s as a TOML string, literal strings are used when
+// possible to keep regular expressions readable.
+func tomlString(s string) string {
+	if !strings.ContainsAny(s, "'\n\r") && strings.Contains(s, `\`) {
+		return "'" + s + "'"
+	}
+
+	var b strings.Builder
+
+	b.WriteByte('"')
+
+	for _, c := range s {
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(c)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, c)
+		default:
+			b.WriteRune(c)
+		}
+	}
+
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// tomlStrings formats values as a TOML array of strings.
+func tomlStrings(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, tomlString(v))
+	}
+
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// tomlKey quotes key when it is not a valid bare key.
+func tomlKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+
+	for _, c := range key {
+		if c != '_' && c != '-' && (c < '0' || c > '9') && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return tomlString(key)
+		}
+	}
+
+	return key
+}
@@ -0,0 +1,240 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v2"
+)
+
+func TestScaffoldRelease(t *testing.T) {
+	const priorFile = `commit = "v1.0.0"
+project_name = "test"
+github_repo = "containerd/test"
+previous = "v0.9.0"
+match_deps = '^github.com/(containerd/[a-zA-Z0-9-]+)$'
+ignore_deps = ["github.com/containerd/log"]
+
+preface = "old preface"
+
+[notes.old]
+title = "Old"
+
+[rename_deps.zz]
+old = "github.com/old/zz"
+new = "github.com/new/zz"
+
+[rename_deps."a.b"]
+old = "github.com/old/ab"
+new = "github.com/new/ab"
+
+[trackers.jira]
+pattern = '\b(PROJ)-([0-9]+)\b'
+url = "https://jira.example.com/browse/$1-$2"
+
+[components.api]
+paths = ["api/"]
+
+[components.proto]
+paths = ["*.proto"]
+
+[changelog]
+exclude = ['^(fixup|squash)! ', '^Merge branch ']
+paths = ["cmd/"]
+
+[bots]
+authors = []
+commits = "collapse"
+
+[contributors.aliases]
+jdoe = ["jane@example.com", "J. Doe"]
+
+[make_deps.runc]
+variable = "RUNC_VERSION"
+repository = "github.com/opencontainers/runc"
+`
+
+	var prior release
+
+	md, err := toml.Decode(priorFile, &prior)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prior.meta = md
+
+	for _, tc := range []struct {
+		tag        string
+		preRelease bool
+	}{
+		{tag: "v1.1.0"},
+		{tag: "v1.1.0-alpha.0", preRelease: true},
+		{tag: "v1.1.0-beta.1", preRelease: true},
+		{tag: "v1.1.0-rc.2", preRelease: true},
+		{tag: "v1.1.0-rc2", preRelease: true},
+		{tag: "v1.1.0-dev"},
+		{tag: "v1.1.0-rcx"},
+	} {
+		t.Run(tc.tag, func(t *testing.T) {
+			data := scaffoldRelease(&prior, tc.tag, "v1.0.0")
+
+			var r release
+
+			md, err := toml.Decode(string(data), &r)
+			if err != nil {
+				t.Fatalf("invalid release file: %v\n%s", err, data)
+			}
+
+			r.meta = md
+
+			if r.Commit != "HEAD" || r.Previous != "v1.0.0" || r.PreRelease != tc.preRelease {
+				t.Errorf("unexpected commit %q, previous %q, pre_release %t", r.Commit, r.Previous, r.PreRelease)
+			}
+
+			if r.ProjectName != prior.ProjectName || r.GithubRepo != prior.GithubRepo || r.MatchDeps != prior.MatchDeps {
+				t.Errorf("project options not carried over\n%s", data)
+			}
+
+			if r.Preface != "" || len(r.Notes) != 0 {
+				t.Errorf("notes carried over\n%s", data)
+			}
+
+			if !reflect.DeepEqual(r.IgnoreDeps, prior.IgnoreDeps) || !reflect.DeepEqual(r.MakeDeps, prior.MakeDeps) {
+				t.Errorf("dependency options not carried over\n%s", data)
+			}
+
+			if !reflect.DeepEqual(r.Trackers, prior.Trackers) {
+				t.Errorf("trackers not carried over\n%s", data)
+			}
+
+			if components := r.orderedComponents(); !reflect.DeepEqual(components, prior.orderedComponents()) {
+				t.Errorf("unexpected components %+v", components)
+			}
+
+			if !reflect.DeepEqual(r.Changelog, prior.Changelog) {
+				t.Errorf("changelog options not carried over\n%s", data)
+			}
+
+			if !reflect.DeepEqual(r.Bots, prior.Bots) {
+				t.Errorf("bot options not carried over\n%s", data)
+			}
+
+			if !reflect.DeepEqual(r.ContributorOptions, prior.ContributorOptions) {
+				t.Errorf("contributor aliases not carried over\n%s", data)
+			}
+
+			if renames := r.orderedRenameDeps(); !reflect.DeepEqual(renames, prior.orderedRenameDeps()) {
+				t.Errorf("unexpected rename_deps %+v", renames)
+			}
+		})
+	}
+}
+
+func TestPriorReleaseFile(t *testing.T) {
+	dir := t.TempDir()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.Chdir(cwd) }) //nolint: errcheck
+
+	for _, name := range []string{"releases/v1.0.0.toml", "hack/v1.0.0.toml", "hack/v1.1.0.toml"} {
+		if err = os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err = os.WriteFile(name, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		output, previous string
+		expected         string
+	}{
+		// stdout uses the releases directory
+		{"", "v1.0.0", filepath.Join("releases", "v1.0.0.toml")},
+		{"other/v1.1.0.toml", "v1.0.0", ""},
+		{"releases/v1.1.0.toml", "v1.0.0", filepath.Join("releases", "v1.0.0.toml")},
+		{"releases/v1.1.0.toml", "v0.9.0", ""},
+		{"releases/v1.1.0.toml", "", ""},
+		// an existing output file replaced with --force keeps its options
+		{"hack/v1.1.0.toml", "v1.0.0", "hack/v1.1.0.toml"},
+	} {
+		if actual := priorReleaseFile(tc.output, tc.previous); actual != tc.expected {
+			t.Errorf("priorReleaseFile(%q, %q) = %q, expected %q", tc.output, tc.previous, actual, tc.expected)
+		}
+	}
+}
+
+func TestInitExistingOutput(t *testing.T) {
+	testRepo(t)
+	testCommit(t, "README.md", "hello\n", "initial commit")
+
+	if _, err := git("tag", "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	const output = "releases/v1.1.0.toml"
+
+	written := "project_name = \"test\"\n\npreface = \"written by hand\"\n\n[notes.big]\ntitle = \"Big change\"\n"
+	testCommit(t, output, written, "add release file")
+
+	app := cli.NewApp()
+	app.Writer = io.Discard
+	app.Commands = []*cli.Command{initCommand}
+
+	// the hand written preface and notes are not replaced
+	if err := app.Run([]string{"release-tool", "init", "-o", output, "v1.1.0"}); err == nil {
+		t.Fatal("expected an error for an existing output file")
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != written {
+		t.Fatalf("output file changed without --force\n%s", data)
+	}
+
+	if err = app.Run([]string{"release-tool", "init", "--force", "-o", output, "v1.1.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var r release
+
+	if _, err = toml.DecodeFile(output, &r); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.ProjectName != "test" || r.Previous != "v1.0.0" || r.Preface != "" || len(r.Notes) != 0 {
+		t.Errorf("unexpected replaced release file %+v", r)
+	}
+}
@@ -0,0 +1,212 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tracker links references to an external issue tracker, such as
+// `JIRA-123`, the URL is expanded with the submatches of the pattern.
+type tracker struct {
+	Name    string `toml:"-" json:"name" yaml:"name"`
+	Pattern string `toml:"pattern" json:"pattern" yaml:"pattern"`
+	URL     string `toml:"url" json:"url" yaml:"url"`
+
+	re *regexp.Regexp
+}
+
+// closingRefs matches issues closed by a commit in its body, such as
+// `Fixes #12` or `Closes owner/repo#34`.
+var closingRefs = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+((?:[\w.-]+/[\w.-]+)?#[0-9]+)\b`)
+
+// orderedTrackers returns the trackers of the release file in declaration
+// order with their patterns compiled.
+func (r *release) orderedTrackers() ([]tracker, error) {
+	names := orderedKeys(r.meta, "trackers", r.Trackers)
+	trackers := make([]tracker, 0, len(names))
+
+	for _, name := range names {
+		t := r.Trackers[name]
+		t.Name = name
+
+		if t.Pattern == "" || t.URL == "" {
+			return nil, fmt.Errorf("tracker %s must set 'pattern' and 'url'", name)
+		}
+
+		re, err := regexp.Compile(t.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for tracker %s: %w", name, err)
+		}
+
+		t.re = re
+		trackers = append(trackers, t)
+	}
+
+	return trackers, nil
+}
+
+// squashRefs match the `(#N)` and `(!N)` pull request references appended
+// to the subject by squash merges.
+var squashRefs = map[string]*regexp.Regexp{
+	"#": regexp.MustCompile(`\((#([0-9]+))\)\s*$`),
+	"!": regexp.MustCompile(`\((!([0-9]+))\)\s*$`),
+}
+
+func squashPattern(f forge) *regexp.Regexp {
+	return squashRefs[f.pullRequestRef("")]
+}
+
+// pullRequestNumber returns the number of the pull request which merged c,
+// either as a merge commit or as a squash merge.
+func pullRequestNumber(f forge, c change) string {
+	if number, _ := f.pullRequest(c); number != "" {
+		return number
+	}
+
+	if m := squashPattern(f).FindStringSubmatch(c.Description); m != nil {
+		return m[2]
+	}
+
+	return ""
+}
+
+// referenceLinks returns a function which links the references in the
+// description of a change: the pull request merged by the change, squash
+// merged `(#N)` suffixes, cross repository `owner/repo#N` references and
+// references to the trackers. Issues closed in the body of the change are
+// appended to the description.
+func referenceLinks(f forge, trackers []tracker) func(change) (string, error) {
+	sigils := regexp.QuoteMeta(f.pullRequestRef(""))
+	if f.kind() == forgeGitLab {
+		sigils = "[#!]"
+	}
+
+	var (
+		squash = squashPattern(f)
+		cross  = regexp.MustCompile(`(^|[\s(])(([\w.-]+/[\w.-]+)(` + sigils + `)([0-9]+))\b`)
+	)
+
+	return func(c change) (string, error) {
+		description := c.Description
+
+		if number, loc := f.pullRequest(c); number != "" {
+			link := markdownRef(f.pullRequestRef(number), f.pullRequestURL(number))
+
+			if loc == nil {
+				description += " " + link
+			} else {
+				description = description[:loc[0]] + link + description[loc[1]:]
+			}
+		}
+
+		description = replaceText(description, squash, func(m []string) string {
+			return "(" + markdownRef(m[1], f.pullRequestURL(m[2])) + ")"
+		})
+
+		description = replaceText(description, cross, func(m []string) string {
+			return m[1] + markdownRef(m[2], crossRefURL(f, m[3], m[4], m[5]))
+		})
+
+		for _, t := range trackers {
+			description = replaceText(description, t.re, func(m []string) string {
+				return markdownRef(m[0], expandTracker(t, m[0]))
+			})
+		}
+
+		var closed []string
+
+		for _, m := range closingRefs.FindAllStringSubmatch(c.body, -1) {
+			ref := m[1]
+			if strings.Contains(description, "["+ref+"]") {
+				continue
+			}
+
+			repo, number, _ := strings.Cut(ref, "#")
+
+			link := markdownRef(ref, f.issueURL(number))
+			if repo != "" {
+				link = markdownRef(ref, crossRefURL(f, repo, "#", number))
+			}
+
+			closed = append(closed, link)
+		}
+
+		if len(closed) > 0 {
+			description += " (fixes " + strings.Join(closed, ", ") + ")"
+		}
+
+		return description, nil
+	}
+}
+
+// crossRefURL returns the URL of an issue or pull request in another
+// repository on the same forge.
+func crossRefURL(f forge, repo, sigil, number string) string {
+	other, err := newForge(f.kind(), f.base(), repo)
+	if err != nil {
+		return f.issueURL(number)
+	}
+
+	if sigil == "!" {
+		return other.pullRequestURL(number)
+	}
+
+	return other.issueURL(number)
+}
+
+func expandTracker(t tracker, ref string) string {
+	m := t.re.FindStringSubmatchIndex(ref)
+
+	return string(t.re.ExpandString(nil, t.URL, ref, m))
+}
+
+func markdownRef(text, url string) string {
+	return fmt.Sprintf("[%s](%s)", text, url)
+}
+
+// replaceText replaces all matches of re in the text outside of the
+// markdown links in s with the result of replace for the submatches, to
+// keep references from being linked twice.
+func replaceText(s string, re *regexp.Regexp, replace func([]string) string) string {
+	return convertLinks(s, func(text string) string {
+		var (
+			b    strings.Builder
+			last int
+		)
+
+		for _, loc := range re.FindAllStringSubmatchIndex(text, -1) {
+			m := make([]string, len(loc)/2)
+
+			for i := range m {
+				if loc[2*i] >= 0 {
+					m[i] = text[loc[2*i]:loc[2*i+1]]
+				}
+			}
+
+			b.WriteString(text[last:loc[0]])
+			b.WriteString(replace(m))
+			last = loc[1]
+		}
+
+		b.WriteString(text[last:])
+
+		return b.String()
+	}, markdownRef)
+}
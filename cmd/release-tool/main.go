@@ -17,88 +17,172 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"os"
-	"path"
 	"path/filepath"
-	"regexp"
-	"sort"
 	"strings"
-	"text/tabwriter"
-	"text/template"
 	"time"
 	"unicode"
 
+	"github.com/BurntSushi/toml"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 )
 
 type note struct {
-	Title       string `toml:"title"`
-	Description string `toml:"description"`
+	Name        string `toml:"-" json:"name" yaml:"name"`
+	Title       string `toml:"title" json:"title" yaml:"title"`
+	Description string `toml:"description" json:"description" yaml:"description"`
 }
 
 type change struct {
-	Commit      string `toml:"commit"`
-	Description string `toml:"description"`
+	Commit      string `toml:"commit" json:"commit" yaml:"commit"`
+	Description string `toml:"description" json:"description" yaml:"description"`
+	Project     string `toml:"project" json:"project" yaml:"project"`
+
+	// conventional commit fields parsed from the description
+	Type     string `toml:"-" json:"type" yaml:"type"`
+	Scope    string `toml:"-" json:"scope" yaml:"scope"`
+	Subject  string `toml:"-" json:"subject" yaml:"subject"`
+	Breaking bool   `toml:"-" json:"breaking" yaml:"breaking"`
+
+	// PullRequest is set with --pull-requests for changes merged through
+	// a GitHub pull request.
+	PullRequest *pullRequest `toml:"-" json:"pull_request" yaml:"pull_request"`
+
+	hash         string
+	authorName   string
+	authorEmail  string
+	body         string
+	breakingNote string
+}
+
+// pullRequest is the metadata of the pull request which merged a change.
+type pullRequest struct {
+	Number      int      `json:"number" yaml:"number"`
+	Title       string   `json:"title" yaml:"title"`
+	Author      string   `json:"author" yaml:"author"`
+	Labels      []string `json:"labels" yaml:"labels"`
+	MergeCommit string   `json:"merge_commit" yaml:"merge_commit"`
+	URL         string   `json:"url" yaml:"url"`
 }
 
 type dependency struct {
-	Name     string
-	Ref      string
-	Sha      string
-	Previous string
-	GitURL   string
+	Name     string `json:"name" yaml:"name"`
+	Ref      string `json:"ref" yaml:"ref"`
+	Sha      string `json:"sha" yaml:"sha"`
+	Previous string `json:"previous" yaml:"previous"`
+	GitURL   string `json:"git_url" yaml:"git_url"`
+
+	// Modules are the paths of the Go modules requiring the dependency,
+	// empty for dependencies of the Makefile.
+	Modules []string `json:"modules" yaml:"modules"`
 }
 
 type download struct {
-	Filename string
-	Hash     string
+	Filename string `json:"filename" yaml:"filename"`
+	Hash     string `json:"hash" yaml:"hash"`
+	SHA512   string `json:"sha512" yaml:"sha512"`
+	Size     int64  `json:"size" yaml:"size"`
 }
 
 type projectChange struct {
-	Name    string
-	Since   string
-	Changes []change
+	Name    string        `json:"name" yaml:"name"`
+	Since   string        `json:"since" yaml:"since"`
+	Changes []change      `json:"changes" yaml:"changes"`
+	Groups  []changeGroup `json:"groups" yaml:"groups"`
+
+	// Component is the name of the component of the project the changes
+	// are in, when components are configured.
+	Component string `json:"component" yaml:"component"`
+
+	// Dependencies are the updated dependencies of the go.mod files of the
+	// component.
+	Dependencies []dependency `json:"dependencies" yaml:"dependencies"`
+
+	// BotCommits is the number of commits of bots collapsed from the
+	// changes.
+	BotCommits int `json:"bot_commits" yaml:"bot_commits"`
+
+	// Categories are the changes of the project grouped by the labels of
+	// their pull requests with .github/release.yml
+	Categories []changeGroup `json:"categories" yaml:"categories"`
 }
 
 type projectRename struct {
-	Old string `toml:"old"`
-	New string `toml:"new"`
+	Name string `toml:"-" json:"name" yaml:"name"`
+	Old  string `toml:"old" json:"old" yaml:"old"`
+	New  string `toml:"new" json:"new" yaml:"new"`
 }
 
 type makeDependency struct {
-	Variable   string `toml:"variable"`
-	Repository string `toml:"repository"`
+	Variable   string `toml:"variable" json:"variable" yaml:"variable"`
+	Repository string `toml:"repository" json:"repository" yaml:"repository"`
 }
 
 type release struct { //nolint: govet
-	ProjectName     string            `toml:"project_name"`
-	GithubRepo      string            `toml:"github_repo"`
-	Commit          string            `toml:"commit"`
-	Previous        string            `toml:"previous"`
-	PreRelease      bool              `toml:"pre_release"`
-	Preface         string            `toml:"preface"`
-	Notes           map[string]note   `toml:"notes"`
-	BreakingChanges map[string]change `toml:"breaking"`
-	ReleaseDate     string            `toml:"release_date"`
+	ProjectName     string            `toml:"project_name" json:"project_name" yaml:"project_name"`
+	GithubRepo      string            `toml:"github_repo" json:"github_repo" yaml:"github_repo"`
+	Forge           string            `toml:"forge" json:"forge" yaml:"forge"`
+	ForgeURL        string            `toml:"forge_url" json:"forge_url" yaml:"forge_url"`
+	Commit          string            `toml:"commit" json:"commit" yaml:"commit"`
+	Previous        string            `toml:"previous" json:"previous" yaml:"previous"`
+	PreRelease      bool              `toml:"pre_release" json:"pre_release" yaml:"pre_release"`
+	Preface         string            `toml:"preface" json:"preface" yaml:"preface"`
+	Notes           map[string]note   `toml:"notes" json:"notes" yaml:"notes"`
+	BreakingChanges map[string]change `toml:"breaking" json:"breaking" yaml:"breaking"`
+	ReleaseDate     string            `toml:"release_date" json:"release_date" yaml:"release_date"`
+
+	// trackers link references to external issue trackers
+	Trackers map[string]tracker `toml:"trackers" json:"trackers" yaml:"trackers"`
 
 	// dependency options
-	MatchDeps  string                    `toml:"match_deps"`
-	RenameDeps map[string]projectRename  `toml:"rename_deps"`
-	IgnoreDeps []string                  `toml:"ignore_deps"`
-	MakeDeps   map[string]makeDependency `toml:"make_deps"`
+	MatchDeps  string                    `toml:"match_deps" json:"match_deps" yaml:"match_deps"`
+	RenameDeps map[string]projectRename  `toml:"rename_deps" json:"rename_deps" yaml:"rename_deps"`
+	IgnoreDeps []string                  `toml:"ignore_deps" json:"ignore_deps" yaml:"ignore_deps"`
+	MakeDeps   map[string]makeDependency `toml:"make_deps" json:"make_deps" yaml:"make_deps"`
+
+	// contributor options
+	ContributorOptions contributorOptions `toml:"contributors" json:"contributor_options" yaml:"contributor_options"`
+	Bots               botOptions         `toml:"bots" json:"bots" yaml:"bots"`
+
+	// components of the project in subdirectories
+	Components map[string]component `toml:"components" json:"components" yaml:"components"`
+
+	// changelog options
+	Changelog    changelogOptions    `toml:"changelog" json:"changelog" yaml:"changelog"`
+	ChangeGroups []changeGroupConfig `toml:"change_groups" json:"change_groups" yaml:"change_groups"`
+	ChglogConfig string              `toml:"chglog_config" json:"chglog_config" yaml:"chglog_config"`
+
+	// artifact options
+	Artifacts       string `toml:"artifacts" json:"artifacts" yaml:"artifacts"`
+	ArtifactsSHA512 bool   `toml:"artifacts_sha512" json:"artifacts_sha512" yaml:"artifacts_sha512"`
 
 	// generated fields
-	Changes      []projectChange
-	Contributors []string
-	Dependencies []dependency
-	Tag          string
-	Version      string
-	Downloads    []download
+	OrderedNotes           []note            `json:"ordered_notes" yaml:"ordered_notes"`
+	OrderedBreakingChanges []change          `json:"ordered_breaking_changes" yaml:"ordered_breaking_changes"`
+	Changes                []projectChange   `json:"changes" yaml:"changes"`
+	ContributorNames       []string          `toml:"-" json:"contributors" yaml:"contributors"`
+	Contributors           []contributor     `toml:"-" json:"contributor_details" yaml:"contributor_details"`
+	NewContributors        []newContributor  `toml:"-" json:"new_contributors" yaml:"new_contributors"`
+	Dependencies           []dependency      `json:"dependencies" yaml:"dependencies"`
+	DependenciesByModule   []dependencyGroup `toml:"-" json:"dependencies_by_module" yaml:"dependencies_by_module"`
+	Tag                    string            `json:"tag" yaml:"tag"`
+	Version                string            `json:"version" yaml:"version"`
+	Downloads              []download        `json:"downloads" yaml:"downloads"`
+	Rollups                []rollup          `json:"rollups" yaml:"rollups"`
+
+	// links generated by the forge of the project
+	ReleaseURL  string `json:"release_url" yaml:"release_url"`
+	PreviousURL string `json:"previous_url" yaml:"previous_url"`
+	CompareURL  string `json:"compare_url" yaml:"compare_url"`
+	IssuesURL   string `json:"issues_url" yaml:"issues_url"`
+
+	meta  toml.MetaData
+	forge forge
 }
 
-//nolint:gocognit,gocyclo,cyclop,maintidx
 func main() {
 	app := cli.NewApp()
 	app.Name = "release"
@@ -122,6 +206,11 @@ This tool should be ran from the root of the project repository for a new releas
 			Aliases: []string{"t"},
 			Usage:   "tag name for the release, defaults to release file name",
 		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "output format of the release notes: markdown, gfm, html, asciidoc or text, or json and yaml for the release data in dry run mode",
+			Value: formatMarkdown,
+		},
 		&cli.StringFlag{
 			Name:  "template",
 			Usage: "template filepath to use in place of the default",
@@ -142,304 +231,283 @@ This tool should be ran from the root of the project repository for a new releas
 			Usage:   "cache directory for static remote resources",
 			EnvVars: []string{"RELEASE_TOOL_CACHE"},
 		},
+		&cli.BoolFlag{
+			Name:    "force",
+			Aliases: []string{"f"},
+			Usage:   "replace an existing tag and allow tagging with a dirty worktree",
+		},
+		&cli.BoolFlag{
+			Name:    "sign",
+			Aliases: []string{"s"},
+			Usage:   "create a signed tag using the default signing key",
+		},
+		&cli.StringFlag{
+			Name:    "sign-key",
+			Aliases: []string{"u"},
+			Usage:   "create a signed tag using the given key",
+		},
+		&cli.StringFlag{
+			Name:  "sign-format",
+			Usage: "signature format to use for the tag (openpgp, x509 or ssh), defaults to git's gpg.format",
+		},
+		&cli.StringFlag{
+			Name:  "artifacts",
+			Usage: "directory of release artifacts to hash and list as downloads",
+		},
+		&cli.BoolFlag{
+			Name:  "sha512",
+			Usage: "also compute SHA-512 checksums for the release artifacts",
+		},
+		&cli.StringFlag{
+			Name:    "github-api",
+			Usage:   "base URL of the GitHub REST API",
+			Value:   defaultGithubAPI,
+			EnvVars: []string{"GITHUB_API_URL"},
+		},
+		&cli.StringFlag{
+			Name:    "github-token",
+			Usage:   "token used to authenticate with the GitHub API",
+			EnvVars: []string{"GITHUB_TOKEN"},
+		},
+		&cli.BoolFlag{
+			Name:  "pull-requests",
+			Usage: "fetch the title, author, labels and merge commit of pull requests from the GitHub API",
+		},
 	}
-	app.Action = func(context *cli.Context) error {
-		var (
-			releasePath = context.Args().First()
-			tag         = context.String("tag")
-			linkify     = context.Bool("linkify")
-			gfm         = context.Bool("gfm")
-		)
-
-		if tag == "" {
-			tag = parseTag(releasePath)
-		}
-
-		version := strings.TrimLeft(tag, "v")
-
+	app.Before = func(context *cli.Context) error {
 		if context.Bool("debug") {
 			logrus.SetLevel(logrus.DebugLevel)
 		}
 
-		var (
-			cache   Cache
-			gitRoot string
-		)
-
-		cd := context.String("cache")
-		if cd == "" {
-			cache = nilCache{}
-		} else {
-			var err error
-
-			cd, err = filepath.Abs(cd)
-			if err != nil {
-				return err
-			}
-
-			if _, err = os.Stat(cd); err != nil {
-				return fmt.Errorf("unable to use cache dir: %w", err)
-			}
-
-			gitRoot = filepath.Join(cd, "git")
-			cacheRoot := filepath.Join(cd, "object")
+		return checkFormat(context.String("format"))
+	}
+	app.Action = func(context *cli.Context) error {
+		r, err := loadReleaseFromContext(context)
+		if err != nil {
+			return err
+		}
 
-			if err = os.MkdirAll(gitRoot, 0o755); err != nil {
-				return fmt.Errorf("unable to mkdir %s: %w", gitRoot, err)
-			}
+		format := context.String("format")
 
-			if err = os.MkdirAll(cacheRoot, 0o755); err != nil {
-				return fmt.Errorf("unable to mkdir: %s: %w", cacheRoot, err)
+		if !context.Bool("dry") {
+			if isDataFormat(format) {
+				return fmt.Errorf("%s output is only supported in dry run mode", format)
 			}
 
-			cache = &dirCache{
-				root: cacheRoot,
+			if _, err = checkTag(r.Tag, r.Commit, context.Bool("force")); err != nil {
+				return err
 			}
 		}
 
-		r, err := loadRelease(releasePath)
-		if err != nil {
+		if err = generateRelease(context, r); err != nil {
 			return err
 		}
 
-		logrus.Infof("Welcome to the %s release tool...", r.ProjectName)
+		if isDataFormat(format) {
+			return writeReleaseData(os.Stdout, format, r)
+		}
 
-		mailmapPath, err := filepath.Abs(".mailmap")
+		tmpl, err := getTemplate(context)
 		if err != nil {
-			return fmt.Errorf("failed to resolve mailmap: %w", err)
+			return err
 		}
 
-		gitConfigs["mailmap.file"] = mailmapPath
+		if context.Bool("dry") {
+			return renderRelease(os.Stdout, format, tmpl, r)
+		}
 
-		var (
-			contributors   = map[contributor]int{}
-			projectChanges = []projectChange{}
-		)
+		var notes bytes.Buffer
 
-		changes, err := changelog(r.Previous, r.Commit)
-		if err != nil {
+		if err = renderRelease(&notes, format, tmpl, r); err != nil {
 			return err
 		}
 
-		if linkify {
-			if err = linkifyChanges(changes, githubCommitLink(r.GithubRepo, gfm), githubPRLink(r.GithubRepo), gfm); err != nil {
-				return err
-			}
+		if err = createTag(r.Tag, r.Commit, notes.Bytes(), tagOptions{
+			Sign:       context.Bool("sign"),
+			SignKey:    context.String("sign-key"),
+			SignFormat: context.String("sign-format"),
+			Force:      context.Bool("force"),
+		}); err != nil {
+			return err
 		}
 
-		projectChanges = append(projectChanges, projectChange{
-			Name:    "",
-			Changes: changes,
-		})
+		logrus.Infof("created tag %s", r.Tag)
 
-		previousTag, err := getPreviousTag(tag)
-		if err != nil {
-			return err
-		}
+		logrus.Info("release complete!")
 
-		if previousTag != "" && previousTag != r.Previous && previousTag != r.Tag {
-			var previousTagChanges []change
+		return nil
+	}
+	app.Commands = []*cli.Command{
+		publishCommand,
+		changelogCommand,
+		validateCommand,
+		initCommand,
+	}
 
-			previousTagChanges, err = changelog(previousTag, r.Commit)
-			if err != nil {
-				return err
-			}
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
 
-			if linkify {
-				if err = linkifyChanges(previousTagChanges, githubCommitLink(r.GithubRepo, gfm), githubPRLink(r.GithubRepo), gfm); err != nil {
-					return err
-				}
-			}
+// loadReleaseFromContext loads the release file given as the first argument
+// and resolves the tag and version for the release.
+func loadReleaseFromContext(context *cli.Context) (*release, error) {
+	var (
+		releasePath = context.Args().First()
+		tag         = context.String("tag")
+	)
 
-			projectChanges = append(projectChanges, projectChange{
-				Name:    "",
-				Since:   previousTag,
-				Changes: previousTagChanges,
-			})
-		}
+	if tag == "" {
+		tag = parseTag(releasePath)
+	}
 
-		if err = addContributors(r.Previous, r.Commit, contributors); err != nil {
-			return err
-		}
+	r, err := loadRelease(releasePath)
+	if err != nil {
+		return nil, err
+	}
 
-		logrus.Infof("creating new release %s with %d new changes...", tag, len(changes))
+	r.Tag = tag
+	r.Version = strings.TrimLeft(tag, "v")
 
-		makeDeps := make([]makeDependency, 0, len(r.MakeDeps))
+	logrus.Infof("Welcome to the %s release tool...", r.ProjectName)
 
-		for _, makeDep := range r.MakeDeps {
-			makeDeps = append(makeDeps, makeDep)
-		}
+	return r, nil
+}
 
-		current, err := parseDependencies(r.Commit, makeDeps)
+// generateRelease fills in the generated fields of r from the repository history.
+//
+//nolint:gocognit,gocyclo,cyclop,maintidx
+func generateRelease(context *cli.Context, r *release) error {
+	var (
+		linkify = context.Bool("linkify")
+		gfm     = context.Bool("gfm") || context.String("format") == formatGFM
+	)
+
+	var (
+		cache   Cache
+		gitRoot string
+	)
+
+	cd := context.String("cache")
+	if cd == "" {
+		cache = nilCache{}
+	} else {
+		var err error
+
+		cd, err = filepath.Abs(cd)
 		if err != nil {
 			return err
 		}
 
-		previous, err := parseDependencies(r.Previous, makeDeps)
-		if err != nil {
-			return err
+		if _, err = os.Stat(cd); err != nil {
+			return fmt.Errorf("unable to use cache dir: %w", err)
 		}
 
-		renameDependencies(previous, r.RenameDeps)
+		gitRoot = filepath.Join(cd, "git")
+		cacheRoot := filepath.Join(cd, "object")
 
-		updatedDeps, err := getUpdatedDeps(previous, current, r.IgnoreDeps, cache)
-		if err != nil {
-			return err
+		if err = os.MkdirAll(gitRoot, 0o755); err != nil {
+			return fmt.Errorf("unable to mkdir %s: %w", gitRoot, err)
 		}
 
-		sort.Slice(updatedDeps, func(i, j int) bool {
-			return updatedDeps[i].Name < updatedDeps[j].Name
-		})
+		if err = os.MkdirAll(cacheRoot, 0o755); err != nil {
+			return fmt.Errorf("unable to mkdir: %s: %w", cacheRoot, err)
+		}
 
-		if r.MatchDeps != "" && len(updatedDeps) > 0 {
-			var re *regexp.Regexp
+		cache = &dirCache{
+			root: cacheRoot,
+		}
+	}
 
-			re, err = regexp.Compile(r.MatchDeps)
-			if err != nil {
-				return fmt.Errorf("unable to compile 'match_deps' regexp: %w", err)
-			}
+	mailmapPath, err := filepath.Abs(".mailmap")
+	if err != nil {
+		return fmt.Errorf("failed to resolve mailmap: %w", err)
+	}
 
-			if gitRoot == "" {
-				var td string
+	gitConfigs["mailmap.file"] = mailmapPath
 
-				td, err = os.MkdirTemp("", "tmp-clone-")
-				if err != nil {
-					return fmt.Errorf("unable to create temp clone directory: %w", err)
-				}
+	if r.forge, err = releaseForge(r); err != nil {
+		return err
+	}
 
-				defer os.RemoveAll(td) //nolint: errcheck
-				gitRoot = td
-			}
+	if r.forge != nil {
+		r.ReleaseURL = r.forge.releaseURL(r.Tag)
+		r.IssuesURL = r.forge.issuesURL()
 
-			var cwd string
+		if r.Previous != "" {
+			r.PreviousURL = r.forge.releaseURL(r.Previous)
+			r.CompareURL = r.forge.compareURL(r.Previous, r.Tag)
+		}
+	}
 
-			cwd, err = os.Getwd()
-			if err != nil {
-				return fmt.Errorf("unable to get cwd: %w", err)
-			}
+	rc, err := newRangeCollector(r, cache, gitRoot, linkify, gfm)
+	if err != nil {
+		return err
+	}
 
-			for _, dep := range updatedDeps {
-				matches := re.FindStringSubmatch(dep.Name)
-				if matches == nil {
-					continue
-				}
-
-				logrus.Debugf("Matched dependency %s with %s", dep.Name, r.MatchDeps)
-
-				var name string
-
-				if len(matches) < 2 {
-					name = path.Base(dep.Name)
-				} else {
-					name = matches[1]
-				}
-
-				if err = os.Chdir(gitRoot); err != nil {
-					return fmt.Errorf("unable to chdir to temp clone directory: %w", err)
-				}
-
-				var cloned bool
-
-				if _, err = os.Stat(name); err != nil && os.IsNotExist(err) {
-					logrus.Debugf("git clone %s %s", dep.GitURL, name)
-
-					if _, err = git("clone", dep.GitURL, name); err != nil {
-						return fmt.Errorf("failed to clone: %w", err)
-					}
-
-					cloned = true
-				} else if err != nil {
-					return fmt.Errorf("unable to stat: %w", err)
-				}
-
-				if err = os.Chdir(name); err != nil {
-					return fmt.Errorf("unable to chdir to cloned %s directory: %w", name, err)
-				}
-
-				if !cloned {
-					if _, err = git("show", dep.Ref); err != nil {
-						logrus.WithField("name", name).Debugf("git fetch origin")
-
-						if _, err = git("fetch", "origin"); err != nil {
-							return fmt.Errorf("failed to fetch: %w", err)
-						}
-					}
-				}
-
-				var changes []change
-
-				changes, err = changelog(dep.Previous, dep.Ref)
-				if err != nil {
-					return fmt.Errorf("failed to get changelog for %s: %w", name, err)
-				}
-
-				if err = addContributors(dep.Previous, dep.Ref, contributors); err != nil {
-					return fmt.Errorf("failed to get authors for %s: %w", name, err)
-				}
-
-				if linkify {
-					if !strings.HasPrefix(dep.Name, "github.com/") {
-						logrus.Debugf("linkify only supported for Github, skipping %s", dep.Name)
-					} else {
-						ghname := dep.Name[11:]
-
-						if err = linkifyChanges(changes, githubCommitLink(ghname, gfm), githubPRLink(ghname), gfm); err != nil {
-							return err
-						}
-					}
-				}
-
-				projectChanges = append(projectChanges, projectChange{
-					Name:    name,
-					Changes: changes,
-				})
-			}
+	defer rc.cleanup()
 
-			if err = os.Chdir(cwd); err != nil {
-				return fmt.Errorf("unable to chdir to previous cwd: %w", err)
-			}
-		}
+	if context.Bool("pull-requests") {
+		rc.github = newGithubClient(context.String("github-api"), context.String("github-token"))
+	} else if rc.config != nil {
+		logrus.Warn("changes are categorized without labels, use --pull-requests to fetch the labels for .github/release.yml")
+	}
 
-		// update the release fields with generated data
-		r.Contributors = orderContributors(contributors)
-		r.Dependencies = updatedDeps
-		r.Changes = projectChanges
-		r.Tag = tag
-		r.Version = version
+	rng, err := rc.collect(context.Context, r.Previous, r.Commit)
+	if err != nil {
+		return err
+	}
+
+	var count int
 
-		if r.ReleaseDate == "" {
-			r.ReleaseDate = time.Now().UTC().Format("2006-01-02")
+	for _, pc := range rng.changes {
+		if pc.Name == "" {
+			count += len(pc.Changes)
 		}
+	}
 
-		// Remove trailing new lines
-		r.Preface = strings.TrimRightFunc(r.Preface, unicode.IsSpace)
+	logrus.Infof("creating new release %s with %d new changes...", r.Tag, count)
 
-		tmpl, err := getTemplate(context)
-		if err != nil {
-			return err
-		}
+	r.OrderedNotes = r.orderedNotes()
+	r.OrderedBreakingChanges = r.orderedBreakingChanges()
 
-		if context.Bool("dry") {
-			t, err := template.New("release-notes").Parse(tmpl)
-			if err != nil {
-				return err
-			}
+	for _, pc := range rng.changes {
+		r.addBreakingChanges(pc.Name, pc.Changes)
+	}
 
-			w := tabwriter.NewWriter(os.Stdout, 8, 8, 2, ' ', 0)
-			if err := t.Execute(w, r); err != nil {
-				return err
-			}
+	// update the release fields with generated data
+	r.Contributors = rng.contributors
+	r.ContributorNames = contributorNames(rng.contributors)
+	r.NewContributors = rng.newContributors
+	r.Dependencies = rng.dependencies
+	r.DependenciesByModule = groupDependencies(rng.dependencies)
+	r.Changes = rng.changes
 
-			return w.Flush()
-		}
+	if r.Rollups, err = rc.rollups(context.Context); err != nil {
+		return err
+	}
 
-		logrus.Info("release complete!")
+	if dir := context.String("artifacts"); dir != "" {
+		r.Artifacts = dir
+	}
 
-		return nil
+	if context.Bool("sha512") {
+		r.ArtifactsSHA512 = true
 	}
 
-	if err := app.Run(os.Args); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	if r.Artifacts != "" {
+		if err = hashArtifacts(r, context.Bool("dry")); err != nil {
+			return err
+		}
 	}
+
+	if r.ReleaseDate == "" {
+		r.ReleaseDate = time.Now().UTC().Format("2006-01-02")
+	}
+
+	// Remove trailing new lines
+	r.Preface = strings.TrimRightFunc(r.Preface, unicode.IsSpace)
+
+	return nil
 }
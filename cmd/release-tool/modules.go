@@ -0,0 +1,311 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/mod/modfile"
+)
+
+const goWork = "go.work"
+
+// goModule is a Go module of the repository with its dependencies.
+type goModule struct {
+	// Path is the module path, empty for a root without go.mod.
+	Path string
+	// File is the go.mod file of the module relative to the root.
+	File string
+	Deps []dependency
+}
+
+// dependencyGroup is the updated dependencies of a module.
+type dependencyGroup struct {
+	Module       string       `json:"module" yaml:"module"`
+	Dependencies []dependency `json:"dependencies" yaml:"dependencies"`
+}
+
+// goModFiles returns the go.mod files of the nested modules at rev, found
+// in the tree or used by go.work. Like the go command, modules in vendor,
+// testdata and directories starting with `.` or `_` are skipped unless
+// they are used by go.work.
+func goModFiles(rev string) ([]string, error) {
+	raw, err := git("ls-tree", "-r", "--name-only", rev)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		files []string
+		seen  = map[string]struct{}{goMod: {}}
+	)
+
+	add := func(file string) {
+		if _, ok := seen[file]; !ok {
+			seen[file] = struct{}{}
+			files = append(files, file)
+		}
+	}
+
+	for _, file := range strings.Split(string(raw), "\n") {
+		if path.Base(file) == goMod && !ignoredModuleDir(path.Dir(file)) {
+			add(file)
+		}
+	}
+
+	if rd, err := fileFromRev(rev, goWork); err == nil {
+		uses, err := parseGoWork(rd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s at %s: %w", goWork, rev, err)
+		}
+
+		for _, dir := range uses {
+			add(path.Join(dir, goMod))
+		}
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// parseGoWork returns the directories of the modules used by the workspace
+// inside of the repository.
+func parseGoWork(r io.Reader) ([]string, error) {
+	contents, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	work, err := modfile.ParseWork(goWork, contents, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+
+	for _, use := range work.Use {
+		dir := path.Clean(use.Path)
+		if dir == "." || path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+			continue
+		}
+
+		dirs = append(dirs, dir)
+	}
+
+	return dirs, nil
+}
+
+// ignoredModuleDir reports whether the go command ignores the modules in
+// dir when matching packages.
+func ignoredModuleDir(dir string) bool {
+	for _, elem := range strings.Split(dir, "/") {
+		if elem == "vendor" || elem == "testdata" || (strings.HasPrefix(elem, ".") && elem != ".") || strings.HasPrefix(elem, "_") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseModules returns the modules of the repository at rev. The root
+// dependencies come from vendor.conf, vendor/modules.txt or go.mod, and
+// nested modules from their go.mod.
+func parseModules(rev string) ([]goModule, error) {
+	if rev == "" {
+		return nil, nil
+	}
+
+	var modules []goModule
+
+	root, err := parseGoDependencies(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	if root != nil {
+		rootPath, _ := goModulePath(rev, goMod)
+		modules = append(modules, goModule{Path: rootPath, File: goMod, Deps: root})
+	}
+
+	files, err := goModFiles(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		rd, err := fileFromRev(rev, file)
+		if err != nil {
+			logrus.Debugf("module %s not found at %s", file, rev)
+
+			continue
+		}
+
+		contents, err := io.ReadAll(rd)
+		if err != nil {
+			return nil, err
+		}
+
+		deps, err := parseGoModDependencies(bytes.NewReader(contents))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s at %s: %w", file, rev, err)
+		}
+
+		modules = append(modules, goModule{
+			Path: modfile.ModulePath(contents),
+			File: file,
+			Deps: deps,
+		})
+	}
+
+	return modules, nil
+}
+
+// excludeModules returns the modules whose go.mod file is not one of files.
+func excludeModules(modules []goModule, files []string) []goModule {
+	if len(files) == 0 {
+		return modules
+	}
+
+	excluded := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		excluded[file] = struct{}{}
+	}
+
+	var kept []goModule
+
+	for _, m := range modules {
+		if _, ok := excluded[m.File]; !ok {
+			kept = append(kept, m)
+		}
+	}
+
+	return kept
+}
+
+// goModulePath returns the module path of the go.mod file at rev.
+func goModulePath(rev, file string) (string, error) {
+	rd, err := fileFromRev(rev, file)
+	if err != nil {
+		return "", err
+	}
+
+	contents, err := io.ReadAll(rd)
+	if err != nil {
+		return "", err
+	}
+
+	return modfile.ModulePath(contents), nil
+}
+
+// getUpdatedModuleDeps returns the dependencies updated in any module, the
+// modules are matched by their go.mod file. Modules added since previous
+// are compared with the dependencies of all previous modules so only
+// dependencies new to the repository are reported as new. Updates of a
+// dependency to the same version in several modules are merged.
+func getUpdatedModuleDeps(previous, current []goModule, renames []projectRename, ignored []string, cache Cache) ([]dependency, error) {
+	var (
+		previousDeps = map[string][]dependency{}
+		allDeps      []dependency
+	)
+
+	for _, m := range previous {
+		renameDependencies(m.Deps, renames)
+		previousDeps[m.File] = m.Deps
+		allDeps = append(allDeps, m.Deps...)
+	}
+
+	var (
+		updated []dependency
+		merged  = map[string]int{}
+	)
+
+	for _, m := range current {
+		prev, ok := previousDeps[m.File]
+		if !ok {
+			prev = allDeps
+		}
+
+		deps, err := getUpdatedDeps(prev, m.Deps, ignored, cache)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get updated dependencies of %s: %w", m.File, err)
+		}
+
+		for _, dep := range deps {
+			key := dep.Name + "@" + dep.Previous + ".." + dep.Ref
+
+			idx, ok := merged[key]
+			if !ok {
+				idx = len(updated)
+				merged[key] = idx
+				updated = append(updated, dep)
+			}
+
+			if m.Path != "" {
+				updated[idx].Modules = append(updated[idx].Modules, m.Path)
+			}
+		}
+	}
+
+	return updated, nil
+}
+
+// groupDependencies groups the dependencies by the modules they belong to,
+// sorted by module path. Dependencies of no module, such as the Makefile
+// dependencies, are grouped last with an empty module.
+func groupDependencies(deps []dependency) []dependencyGroup {
+	var (
+		groups []dependencyGroup
+		other  []dependency
+		byPath = map[string]int{}
+	)
+
+	for _, dep := range deps {
+		if len(dep.Modules) == 0 {
+			other = append(other, dep)
+
+			continue
+		}
+
+		for _, module := range dep.Modules {
+			idx, ok := byPath[module]
+			if !ok {
+				idx = len(groups)
+				byPath[module] = idx
+				groups = append(groups, dependencyGroup{Module: module})
+			}
+
+			groups[idx].Dependencies = append(groups[idx].Dependencies, dep)
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Module < groups[j].Module
+	})
+
+	if len(other) > 0 {
+		groups = append(groups, dependencyGroup{Dependencies: other})
+	}
+
+	return groups
+}
@@ -0,0 +1,135 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestModuleDependencies(t *testing.T) {
+	const (
+		oldA = "v0.0.0-20200101000000-aaaaaaaaaaaa"
+		newA = "v0.0.0-20210101000000-bbbbbbbbbbbb"
+	)
+
+	testRepo(t)
+	testCommit(t, "go.mod", "module example.com/project\n\nrequire example.com/a "+oldA+"\n\nreplace example.com/project/api => ./api\n", "initial commit")
+	testCommit(t, "api/go.mod", "module example.com/project/api\n\nrequire example.com/a "+oldA+"\n", "api: add module")
+	testCommit(t, "go.work", "go 1.21\n\nuse (\n\t.\n\t./api\n\t./_examples\n\t../outside\n)\n", "add workspace")
+
+	if _, err := git("tag", "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	testCommit(t, "go.mod", "module example.com/project\n\nrequire (\n\texample.com/a "+newA+"\n\texample.com/project/api v1.0.0\n)\n\nreplace example.com/project/api => ./api\n", "update a")
+	testCommit(t, "api/go.mod", "module example.com/project/api\n\nrequire (\n\texample.com/a "+newA+"\n\texample.com/b v1.0.0\n)\n", "api: update a, add b")
+	testCommit(t, "tools/go.mod", "module example.com/project/tools\n\nrequire example.com/a "+oldA+"\n", "tools: add module")
+	testCommit(t, "_examples/go.mod", "module example.com/project/examples\n\nrequire example.com/c v1.0.0\n", "examples: add module")
+	testCommit(t, "testdata/go.mod", "module example.com/testdata\n\nrequire example.com/ignored v1.0.0\n", "add testdata")
+
+	files, err := goModFiles("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"_examples/go.mod", "api/go.mod", "tools/go.mod"}; !reflect.DeepEqual(files, expected) {
+		t.Errorf("unexpected go.mod files\n got: %v\nwant: %v", files, expected)
+	}
+
+	r := &release{Commit: "HEAD", Previous: "v1.0.0"}
+
+	rc, err := newRangeCollector(r, nilCache{}, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := rc.updatedDependencies(r.Previous, r.Commit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual []string
+
+	for _, d := range deps {
+		actual = append(actual, d.Name+"@"+d.Previous+".."+d.Ref+" "+strings.Join(d.Modules, ","))
+	}
+
+	sort.Strings(actual)
+
+	// the api module replaced by a directory is part of the project and
+	// the unchanged dependency of the new tools module is not reported
+	expected := []string{
+		"example.com/a@aaaaaaaaaaaa..bbbbbbbbbbbb example.com/project,example.com/project/api",
+		"example.com/b@..v1.0.0 example.com/project/api",
+		"example.com/c@..v1.0.0 example.com/project/examples",
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected dependencies\n got: %v\nwant: %v", actual, expected)
+	}
+
+	var groups []string
+
+	for _, g := range groupDependencies(deps) {
+		names := make([]string, 0, len(g.Dependencies))
+		for _, d := range g.Dependencies {
+			names = append(names, d.Name)
+		}
+
+		sort.Strings(names)
+		groups = append(groups, g.Module+": "+strings.Join(names, ","))
+	}
+
+	expectedGroups := []string{
+		"example.com/project: example.com/a",
+		"example.com/project/api: example.com/a,example.com/b",
+		"example.com/project/examples: example.com/c",
+	}
+
+	if !reflect.DeepEqual(groups, expectedGroups) {
+		t.Errorf("unexpected dependency groups\n got: %v\nwant: %v", groups, expectedGroups)
+	}
+
+	// the dependencies of the module of a component are listed with the
+	// component only
+	r.Components = map[string]component{"api": {Paths: []string{"api/"}}}
+
+	deps, err = rc.updatedDependencies(r.Previous, r.Commit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual = nil
+
+	for _, d := range deps {
+		actual = append(actual, d.Name+"@"+d.Previous+".."+d.Ref+" "+strings.Join(d.Modules, ","))
+	}
+
+	sort.Strings(actual)
+
+	expected = []string{
+		"example.com/a@aaaaaaaaaaaa..bbbbbbbbbbbb example.com/project",
+		"example.com/c@..v1.0.0 example.com/project/examples",
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected dependencies with components\n got: %v\nwant: %v", actual, expected)
+	}
+}
@@ -0,0 +1,275 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/russross/blackfriday/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatMarkdown = "markdown"
+	formatGFM      = "gfm"
+	formatHTML     = "html"
+	formatAsciiDoc = "asciidoc"
+	formatText     = "text"
+	formatJSON     = "json"
+	formatYAML     = "yaml"
+)
+
+// renderer renders the release notes in a specific output format.
+type renderer struct {
+	funcs    map[string]any
+	template string
+	// html parses the template with html/template for contextual escaping
+	html bool
+	// tabwriter aligns tab separated columns in the output
+	tabwriter bool
+}
+
+// renderers are the built-in release notes formats. All renderers provide
+// the template functions `inline`, which formats a single line of text that
+// may contain markdown links such as linkified commits and descriptions,
+// `markdown`, which formats a markdown paragraph such as the preface, and
+// `underline`.
+var renderers = map[string]renderer{
+	formatMarkdown: {
+		template:  releaseNotes,
+		tabwriter: true,
+		funcs: map[string]any{
+			"inline":    escapeMarkdown,
+			"markdown":  identity,
+			"underline": underline,
+			"gfm":       func() bool { return false },
+		},
+	},
+	formatGFM: {
+		template:  releaseNotes,
+		tabwriter: true,
+		funcs: map[string]any{
+			"inline":    escapeMarkdown,
+			"markdown":  identity,
+			"underline": underline,
+			"gfm":       func() bool { return true },
+		},
+	},
+	formatHTML: {
+		template: htmlReleaseNotes,
+		html:     true,
+		funcs: map[string]any{
+			"inline":    inlineHTML,
+			"markdown":  markdownHTML,
+			"underline": underline,
+		},
+	},
+	formatAsciiDoc: {
+		template: asciidocReleaseNotes,
+		funcs: map[string]any{
+			"inline":    inlineAsciiDoc,
+			"markdown":  identity,
+			"underline": underline,
+		},
+	},
+	formatText: {
+		template:  textReleaseNotes,
+		tabwriter: true,
+		funcs: map[string]any{
+			"inline":    inlineText,
+			"markdown":  identity,
+			"underline": underline,
+		},
+	},
+}
+
+// isDataFormat reports whether format outputs the release model instead of
+// rendered release notes.
+func isDataFormat(format string) bool {
+	return format == formatJSON || format == formatYAML
+}
+
+// checkFormat verifies format is a known output format.
+func checkFormat(format string) error {
+	if _, ok := renderers[format]; ok || isDataFormat(format) {
+		return nil
+	}
+
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// renderRelease executes the release notes template tmpl for r into w using
+// the renderer for format.
+func renderRelease(w io.Writer, format, tmpl string, r *release) error {
+	rd, ok := renderers[format]
+	if !ok {
+		return fmt.Errorf("unknown output format %q", format)
+	}
+
+	var (
+		t interface {
+			Execute(io.Writer, any) error
+		}
+		err error
+	)
+
+	if rd.html {
+		t, err = htmltemplate.New("release-notes").Funcs(rd.funcs).Parse(tmpl)
+	} else {
+		t, err = template.New("release-notes").Funcs(rd.funcs).Parse(tmpl)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !rd.tabwriter {
+		return t.Execute(w, r)
+	}
+
+	tw := tabwriter.NewWriter(w, 8, 8, 2, ' ', 0)
+	if err = t.Execute(tw, r); err != nil {
+		return err
+	}
+
+	return tw.Flush()
+}
+
+// markdownLink matches inline markdown links.
+var markdownLink = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]+)\)`)
+
+// convertLinks splits s into plain text and markdown links and formats
+// them with text and link. Links which are not web links are formatted as
+// plain text.
+func convertLinks(s string, text func(string) string, link func(text, url string) string) string {
+	var (
+		b    strings.Builder
+		last int
+	)
+
+	for _, m := range markdownLink.FindAllStringSubmatchIndex(s, -1) {
+		if !isWebLink(s[m[4]:m[5]]) {
+			continue
+		}
+
+		b.WriteString(text(s[last:m[0]]))
+		b.WriteString(link(s[m[2]:m[3]], s[m[4]:m[5]]))
+		last = m[1]
+	}
+
+	b.WriteString(text(s[last:]))
+
+	return b.String()
+}
+
+// isWebLink reports whether link is an http, https or relative URL, other
+// schemes such as javascript are not linked.
+func isWebLink(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	return u.Scheme == "" || u.Scheme == "http" || u.Scheme == "https"
+}
+
+func identity(s string) string {
+	return s
+}
+
+func underline(char, s string) string {
+	return s + "\n" + strings.Repeat(char, utf8.RuneCountInString(s))
+}
+
+// htmlTag matches text which would be interpreted as an HTML tag.
+var htmlTag = regexp.MustCompile(`<([a-zA-Z/!])`)
+
+// escapeMarkdown escapes text which markdown renderers would interpret as
+// HTML tags, links are kept as is.
+func escapeMarkdown(s string) string {
+	return htmlTag.ReplaceAllString(s, `\<$1`)
+}
+
+// codeSpan matches markdown code spans.
+var codeSpan = regexp.MustCompile("`([^`]+)`")
+
+func inlineHTML(s string) htmltemplate.HTML {
+	code := func(s string) string {
+		return codeSpan.ReplaceAllString(html.EscapeString(s), "<code>$1</code>")
+	}
+
+	return htmltemplate.HTML(convertLinks(s, html.EscapeString, func(text, url string) string { //nolint: gosec
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), code(text))
+	}))
+}
+
+func markdownHTML(s string) htmltemplate.HTML {
+	return htmltemplate.HTML(blackfriday.Run([]byte(s))) //nolint: gosec
+}
+
+// escapeAsciiDoc passes text through without AsciiDoc formatting.
+func escapeAsciiDoc(s string) string {
+	if !strings.ContainsAny(s, "*_`#^~[]+<>{}\\'&") {
+		return s
+	}
+
+	return "pass:c[" + strings.ReplaceAll(s, "]", "\\]") + "]"
+}
+
+func inlineAsciiDoc(s string) string {
+	return convertLinks(s, escapeAsciiDoc, func(text, url string) string {
+		return fmt.Sprintf("link:%s[%s]", url, strings.ReplaceAll(text, "]", "\\]"))
+	})
+}
+
+func inlineText(s string) string {
+	return convertLinks(s, identity, func(text, _ string) string {
+		return strings.ReplaceAll(text, "`", "")
+	})
+}
+
+// writeReleaseData writes the fully populated release model to w.
+func writeReleaseData(w io.Writer, format string, r *release) error {
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(r)
+	case formatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+
+		return enc.Close()
+	default:
+		return fmt.Errorf("unknown data format %q", format)
+	}
+}
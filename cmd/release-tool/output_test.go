@@ -0,0 +1,296 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderRelease(t *testing.T) {
+	r := &release{
+		ProjectName:  "release-tool",
+		GithubRepo:   "containerd/release-tool",
+		Tag:          "v1.0.0",
+		Version:      "1.0.0",
+		Preface:      "Some **bold** text",
+		Contributors: []contributor{{Name: "Jane <Doe>", Commits: 1}},
+		NewContributors: []newContributor{
+			{
+				contributor: contributor{Name: "Jane <Doe>", Login: "jdoe", Commits: 1},
+				FirstCommit: change{Commit: "[`abc1234`](https://github.com/containerd/release-tool/commit/abc1234)"},
+			},
+		},
+		Changes: []projectChange{
+			{
+				Changes: []change{
+					{
+						Commit:      "[`abc1234`](https://github.com/containerd/release-tool/commit/abc1234)",
+						Description: "fix: handle <nil> values [#12](https://github.com/containerd/release-tool/pull/12)",
+					},
+				},
+				BotCommits: 2,
+			},
+			{
+				Component:    "api",
+				Changes:      []change{{Commit: "fed4321", Description: "api: add field"}},
+				Dependencies: []dependency{{Name: "example.com/dep", Ref: "v1.1.0", Previous: "v1.0.0"}},
+			},
+		},
+		Rollups: []rollup{
+			{
+				Label:        "last stable release",
+				Since:        "v0.9.0",
+				Changes:      []projectChange{{Since: "v0.9.0", Changes: []change{{Commit: "def5678", Description: "feat: add rollups"}}}},
+				Contributors: []contributor{{Name: "Jane <Doe>"}, {Name: "John"}},
+			},
+		},
+		Dependencies: []dependency{
+			{Name: "example.com/a", Ref: "v1.1.0", Previous: "v1.0.0", Modules: []string{"example.com/project"}},
+			{Name: "example.com/runc", Ref: "v1.2.0"},
+		},
+	}
+	r.DependenciesByModule = groupDependencies(r.Dependencies)
+
+	for _, tc := range []struct {
+		format   string
+		contains []string
+	}{
+		{
+			formatMarkdown,
+			[]string{
+				"* [`abc1234`](https://github.com/containerd/release-tool/commit/abc1234) fix: handle \\<nil> values [#12](https://github.com/containerd/release-tool/pull/12)",
+				"Some **bold** text",
+				"### Changes since v0.9.0\n\nAll changes since the last stable release v0.9.0.\n\n<details><summary>1 commit</summary>",
+				"<details><summary>2 contributors</summary>",
+				"### Changes (api)\n<details><summary>1 commit</summary>",
+				"#### Dependency Changes (api)\n\n* **example.com/dep**",
+				"### Dependency Changes\n\n#### example.com/project\n\n* **example.com/a**",
+				"#### Other dependencies\n\n* **example.com/runc**",
+				"### New Contributors\n\n* Jane <Doe> (@jdoe) made their first contribution in [`abc1234`](https://github.com/containerd/release-tool/commit/abc1234)",
+			},
+		},
+		{
+			formatGFM,
+			[]string{
+				"### Contributors\n\n* Jane <Doe>\n",
+				"### New Contributors\n\n* @jdoe made their first contribution in [`abc1234`](https://github.com/containerd/release-tool/commit/abc1234)",
+			},
+		},
+		{
+			formatHTML,
+			[]string{
+				`<li><a href="https://github.com/containerd/release-tool/commit/abc1234"><code>abc1234</code></a> fix: handle &lt;nil&gt; values <a href="https://github.com/containerd/release-tool/pull/12">#12</a></li>`,
+				"<p>Some <strong>bold</strong> text</p>",
+				"<li>Jane &lt;Doe&gt;</li>",
+				"<li>2 dependency update commits</li>",
+				"<h4>Dependency Changes (api)</h4>",
+				"<h3>Dependency Changes</h3>\n\n<h4>example.com/project</h4>\n<ul>\n<li><strong>example.com/a</strong>",
+				`<li>Jane &lt;Doe&gt; (@jdoe) made their first contribution in <a href="https://github.com/containerd/release-tool/commit/abc1234"><code>abc1234</code></a></li>`,
+			},
+		},
+		{
+			formatAsciiDoc,
+			[]string{
+				"* link:https://github.com/containerd/release-tool/commit/abc1234[`abc1234`] pass:c[fix: handle <nil> values ]link:https://github.com/containerd/release-tool/pull/12[#12]",
+				"=== Contributors",
+				"==== Other dependencies\n\n* *example.com/runc* v1.2.0 *_new_*",
+			},
+		},
+		{
+			formatText,
+			[]string{
+				"release-tool 1.0.0 ()\n=====================",
+				"* abc1234 fix: handle <nil> values #12\n* 2 dependency update commits",
+				"Changes since v0.9.0\n--------------------",
+				"Commits\n~~~~~~~\n\n* def5678 feat: add rollups",
+				"example.com/project\n~~~~~~~~~~~~~~~~~~~\n\n* example.com/a",
+				"New Contributors\n----------------\n\n* Jane <Doe> (@jdoe) made their first contribution in abc1234",
+			},
+		},
+	} {
+		var b bytes.Buffer
+
+		if err := renderRelease(&b, tc.format, renderers[tc.format].template, r); err != nil {
+			t.Fatalf("[%s] %v", tc.format, err)
+		}
+
+		for _, s := range tc.contains {
+			if !strings.Contains(b.String(), s) {
+				t.Errorf("[%s] expected output to contain %q:\n%s", tc.format, s, b.String())
+			}
+		}
+	}
+}
+
+func TestInlineHTML(t *testing.T) {
+	for _, tc := range []struct {
+		s        string
+		expected string
+	}{
+		{"fix: handle <nil> values", "fix: handle &lt;nil&gt; values"},
+		{"[`abc1234`](https://github.com/containerd/release-tool/commit/abc1234)", `<a href="https://github.com/containerd/release-tool/commit/abc1234"><code>abc1234</code></a>`},
+		{"see [docs](/docs/index.html)", `see <a href="/docs/index.html">docs</a>`},
+		// links in commit subjects with other schemes are not linked
+		{"fix: [x](javascript:alert%281%29)", "fix: [x](javascript:alert%281%29)"},
+		{"[x](JavaScript:alert(1)) and [y](data:text/html,<b>)", "[x](JavaScript:alert(1)) and [y](data:text/html,&lt;b&gt;)"},
+		{`["><script>](https://example.com/"onclick="x)`, `<a href="https://example.com/&#34;onclick=&#34;x">&#34;&gt;&lt;script&gt;</a>`},
+	} {
+		if actual := string(inlineHTML(tc.s)); actual != tc.expected {
+			t.Errorf("inlineHTML(%q) = %q, expected %q", tc.s, actual, tc.expected)
+		}
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	for _, tc := range []struct {
+		s        string
+		expected string
+	}{
+		// text looking like HTML tags is hidden by markdown renderers
+		{"fix: handle <nil> values", `fix: handle \<nil> values`},
+		{"remove </div> and <!-- comments -->", `remove \</div> and \<!-- comments -->`},
+		{"a < b and b <= c", "a < b and b <= c"},
+		{"[#12](https://github.com/containerd/release-tool/pull/12)", "[#12](https://github.com/containerd/release-tool/pull/12)"},
+	} {
+		if actual := escapeMarkdown(tc.s); actual != tc.expected {
+			t.Errorf("escapeMarkdown(%q) = %q, expected %q", tc.s, actual, tc.expected)
+		}
+	}
+}
+
+func TestWriteReleaseData(t *testing.T) {
+	// the field names of the release data are a stable interface
+	releaseKeys := []string{
+		"artifacts", "artifacts_sha512", "bots", "breaking", "change_groups", "changelog", "changes",
+		"chglog_config", "commit", "compare_url", "components", "contributor_details", "contributor_options",
+		"contributors", "dependencies", "dependencies_by_module", "downloads", "forge", "forge_url",
+		"github_repo", "ignore_deps", "issues_url", "make_deps", "match_deps", "new_contributors", "notes",
+		"ordered_breaking_changes", "ordered_notes", "pre_release", "preface", "previous", "previous_url",
+		"project_name", "release_date", "release_url", "rename_deps", "rollups", "tag", "trackers", "version",
+	}
+	rollupKeys := []string{"changes", "contributor_details", "contributors", "dependencies", "label", "new_contributors", "since"}
+	contributorKeys := []string{"commits", "email", "login", "name"}
+
+	contributors := []contributor{
+		{Name: "Jane Doe", Email: "jane@example.com", Login: "jdoe", Commits: 2},
+		{Name: "John", Email: "john@example.com", Commits: 1},
+	}
+
+	r := &release{
+		ProjectName:      "release-tool",
+		Tag:              "v1.0.0",
+		Version:          "1.0.0",
+		Previous:         "v0.9.0",
+		ContributorNames: contributorNames(contributors),
+		Contributors:     contributors,
+		Dependencies: []dependency{
+			{Name: "example.com/dep", Ref: "v1.1.0", Previous: "v1.0.0", Modules: []string{"example.com/project"}},
+		},
+		Downloads: []download{{Filename: "tool", Hash: "abc", Size: 3}},
+		Rollups: []rollup{
+			{Label: "last stable release", Since: "v0.9.0", ContributorNames: contributorNames(contributors), Contributors: contributors},
+		},
+	}
+
+	for _, tc := range []struct {
+		format    string
+		unmarshal func([]byte, any) error
+	}{
+		{formatJSON, json.Unmarshal},
+		{formatYAML, yaml.Unmarshal},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			var b bytes.Buffer
+
+			if err := writeReleaseData(&b, tc.format, r); err != nil {
+				t.Fatal(err)
+			}
+
+			var (
+				fields map[string]any
+				data   struct {
+					Contributors       []string         `json:"contributors" yaml:"contributors"`
+					ContributorDetails []map[string]any `json:"contributor_details" yaml:"contributor_details"`
+					Rollups            []map[string]any `json:"rollups" yaml:"rollups"`
+				}
+			)
+
+			if err := tc.unmarshal(b.Bytes(), &fields); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tc.unmarshal(b.Bytes(), &data); err != nil {
+				t.Fatal(err)
+			}
+
+			if keys := sortedKeys(fields); !reflect.DeepEqual(keys, releaseKeys) {
+				t.Errorf("unexpected release fields\n got: %q\nwant: %q", keys, releaseKeys)
+			}
+
+			if len(data.Rollups) != 1 {
+				t.Fatalf("unexpected rollups %v", data.Rollups)
+			}
+
+			if keys := sortedKeys(data.Rollups[0]); !reflect.DeepEqual(keys, rollupKeys) {
+				t.Errorf("unexpected rollup fields\n got: %q\nwant: %q", keys, rollupKeys)
+			}
+
+			// contributors are listed by name like in earlier releases
+			if !reflect.DeepEqual(data.Contributors, []string{"Jane Doe", "John"}) {
+				t.Errorf("unexpected contributors %v", data.Contributors)
+			}
+
+			// the keys of contributors are present even when unknown
+			for _, details := range data.ContributorDetails {
+				if keys := sortedKeys(details); !reflect.DeepEqual(keys, contributorKeys) {
+					t.Errorf("unexpected contributor fields\n got: %q\nwant: %q", keys, contributorKeys)
+				}
+			}
+
+			var decoded release
+
+			if err := tc.unmarshal(b.Bytes(), &decoded); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(decoded.Contributors, r.Contributors) ||
+				!reflect.DeepEqual(decoded.Dependencies, r.Dependencies) ||
+				!reflect.DeepEqual(decoded.Downloads, r.Downloads) ||
+				!reflect.DeepEqual(decoded.Rollups[0].Contributors, r.Rollups[0].Contributors) {
+				t.Errorf("release data does not round trip\n got: %+v\nwant: %+v", decoded, *r)
+			}
+		})
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,97 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+var publishCommand = &cli.Command{
+	Name:      "publish",
+	Usage:     "create or update the GitHub release with the rendered release notes",
+	ArgsUsage: "<release file>",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "draft",
+			Usage: "create the release as a draft",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		r, err := loadReleaseFromContext(context)
+		if err != nil {
+			return err
+		}
+
+		f, err := releaseForge(r)
+		if err != nil {
+			return err
+		}
+
+		if f == nil || f.kind() != forgeGitHub {
+			return errors.New("publishing is only supported for GitHub, 'github_repo' must be set to publish a release")
+		}
+
+		if format := context.String("format"); isDataFormat(format) {
+			return fmt.Errorf("%s output can not be published", format)
+		}
+
+		if err = generateRelease(context, r); err != nil {
+			return err
+		}
+
+		tmpl, err := getTemplate(context)
+		if err != nil {
+			return err
+		}
+
+		var notes bytes.Buffer
+
+		if err = renderRelease(&notes, context.String("format"), tmpl, r); err != nil {
+			return err
+		}
+
+		rel := githubRelease{
+			TagName:    r.Tag,
+			Name:       r.Tag,
+			Body:       strings.TrimSpace(notes.String()),
+			Draft:      context.Bool("draft"),
+			Prerelease: r.PreRelease,
+		}
+
+		// target is only used by GitHub when the tag does not exist yet
+		if sha, err := resolveCommit(r.Commit); err == nil {
+			rel.Target = sha
+		}
+
+		client := newGithubClient(context.String("github-api"), context.String("github-token"))
+
+		published, err := client.publishRelease(context.Context, f.repo(), rel)
+		if err != nil {
+			return err
+		}
+
+		logrus.Infof("published release %s: %s", published.TagName, published.HTMLURL)
+
+		return nil
+	},
+}
@@ -0,0 +1,149 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type tagOptions struct {
+	// Sign creates a signed tag using the default signing key.
+	Sign bool
+	// SignKey creates a signed tag using the given key, implies Sign.
+	SignKey string
+	// SignFormat overrides git's gpg.format (openpgp, x509 or ssh).
+	SignFormat string
+	// Force replaces an existing tag and ignores a dirty worktree.
+	Force bool
+}
+
+// checkTag verifies that tag can be created on commit, it returns
+// the resolved commit sha.
+func checkTag(tag, commit string, force bool) (string, error) {
+	sha, err := resolveCommit(commit)
+	if err != nil {
+		return "", err
+	}
+
+	if force {
+		return sha, nil
+	}
+
+	exists, err := tagExists(tag)
+	if err != nil {
+		return "", err
+	}
+
+	if exists {
+		return "", fmt.Errorf("tag %s already exists, use --force to replace it", tag)
+	}
+
+	dirty, err := worktreeDirty()
+	if err != nil {
+		return "", err
+	}
+
+	if dirty {
+		return "", errors.New("worktree has uncommitted changes, use --force to tag anyway")
+	}
+
+	return sha, nil
+}
+
+// createTag creates an annotated tag on commit with message as the tag message.
+func createTag(tag, commit string, message []byte, opts tagOptions) error {
+	sha, err := checkTag(tag, commit, opts.Force)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp("", "release-tag-*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		f.Close()           //nolint: errcheck
+		os.Remove(f.Name()) //nolint: errcheck
+	}()
+
+	if _, err = f.Write(message); err != nil {
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	var args []string
+
+	if opts.SignFormat != "" {
+		args = append(args, "-c", "gpg.format="+opts.SignFormat)
+	}
+
+	// use whitespace cleanup so markdown headers are not stripped as comments
+	args = append(args, "tag", "-a", "--cleanup=whitespace")
+
+	switch {
+	case opts.SignKey != "":
+		args = append(args, "-u", opts.SignKey)
+	case opts.Sign:
+		args = append(args, "-s")
+	}
+
+	if opts.Force {
+		args = append(args, "-f")
+	}
+
+	args = append(args, "-F", f.Name(), tag, sha)
+
+	if _, err = git(args...); err != nil {
+		return fmt.Errorf("failed to create tag %s: %w", tag, err)
+	}
+
+	return nil
+}
+
+func resolveCommit(commit string) (string, error) {
+	o, err := git("rev-parse", "--verify", "--end-of-options", commit+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve commit %q: %w", commit, err)
+	}
+
+	return strings.TrimSpace(string(o)), nil
+}
+
+func tagExists(tag string) (bool, error) {
+	o, err := git("tag", "-l", tag)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(string(o)) != "", nil
+}
+
+func worktreeDirty() (bool, error) {
+	o, err := git("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(string(o)) != "", nil
+}
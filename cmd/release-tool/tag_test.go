@@ -0,0 +1,123 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRepo creates a temporary git repository and changes into it for the
+// duration of the test.
+func testRepo(t *testing.T) string {
+	t.Helper()
+
+	// reading the changelog adds the repository to the global git config,
+	// which is kept out of the home of the user
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	dir := t.TempDir()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		os.Chdir(cwd) //nolint: errcheck
+	})
+
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.name", "Test User"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "tag.gpgSign", "false"},
+	} {
+		if _, err = git(args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func testCommit(t *testing.T, file, content, message string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := git("add", file); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := git("commit", "-q", "-m", message); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateTag(t *testing.T) {
+	testRepo(t)
+	testCommit(t, "README.md", "hello\n", "initial commit")
+
+	message := []byte("## Release v1.0.0\n\n# not a comment\n")
+
+	if err := createTag("v1.0.0", "HEAD", message, tagOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := git("tag", "-l", "--format=%(objecttype) %(contents)", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(o), "tag ## Release v1.0.0") || !strings.Contains(string(o), "# not a comment") {
+		t.Fatalf("unexpected tag contents %q", o)
+	}
+
+	if err = createTag("v1.0.0", "HEAD", message, tagOptions{}); err == nil {
+		t.Fatal("expected error for existing tag")
+	}
+
+	if err = createTag("v1.0.0", "HEAD", message, tagOptions{Force: true}); err != nil {
+		t.Fatalf("unexpected error with force: %v", err)
+	}
+
+	if err = createTag("v1.0.1", "does-not-exist", message, tagOptions{Force: true}); err == nil {
+		t.Fatal("expected error for unresolvable commit")
+	}
+
+	if err = os.WriteFile("README.md", []byte("changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = createTag("v1.0.1", "HEAD", message, tagOptions{}); err == nil {
+		t.Fatal("expected error for dirty worktree")
+	}
+}
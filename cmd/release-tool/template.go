@@ -18,7 +18,7 @@ package main
 
 const (
 	defaultTemplateFile = "TEMPLATE"
-	releaseNotes        = `## [{{.ProjectName}} {{.Version}}](https://github.com/{{.GithubRepo}}/releases/tag/v{{.Version}}) ({{.ReleaseDate}})
+	releaseNotes        = `## {{if .ReleaseURL}}[{{.ProjectName}} {{.Version}}]({{.ReleaseURL}}){{else}}{{.ProjectName}} {{.Version}}{{end}} ({{.ReleaseDate}})
 
 Welcome to the {{.Tag}} release of {{.ProjectName}}!
 {{- if .PreRelease }}  {{/* two spaces added for markdown newline*/}}
@@ -27,45 +27,544 @@ Welcome to the {{.Tag}} release of {{.ProjectName}}!
 
 {{.Preface}}
 
-Please try out the release binaries and report any issues at
-https://github.com/{{.GithubRepo}}/issues.
+Please try out the release binaries and report any issues
+{{- if .IssuesURL}} at
+{{.IssuesURL}}{{end}}.
 
-{{- range  $note := .Notes}}
+{{- range  $note := .OrderedNotes}}
 
 ### {{$note.Title}}
 
 {{$note.Description}}
 {{- end}}
 
+{{- if .OrderedBreakingChanges}}
+
+### Breaking Changes
+{{range $change := .OrderedBreakingChanges}}
+* {{if $change.Project}}**{{$change.Project}}**: {{end}}{{inline $change.Description}}{{if $change.Commit}} ({{$change.Commit}}){{end}}
+{{- end}}
+{{- end}}
+
 ### Contributors
 {{range $contributor := .Contributors}}
-* {{$contributor}}
+* {{if and gfm $contributor.Login}}@{{$contributor.Login}}{{else}}{{$contributor}}{{end}}
+{{- end}}
+{{- if .NewContributors}}
+
+### New Contributors
+{{range $contributor := .NewContributors}}
+* {{if and gfm $contributor.Login}}@{{$contributor.Login}}{{else}}{{$contributor}}{{with $contributor.Login}} (@{{.}}){{end}}{{end}} made their first contribution in {{if $contributor.FirstCommit.Project}}{{$contributor.FirstCommit.Project}} {{end}}{{$contributor.FirstCommit.Commit}}
+{{- end}}
 {{- end -}}
 
 {{range $project := .Changes}}
 
-### Changes{{if $project.Name}} from {{$project.Name}}{{end}}{{if $project.Since}} since {{$project.Since}}{{end}}
+### Changes{{if $project.Component}} ({{$project.Component}}){{end}}{{if $project.Name}} from {{$project.Name}}{{end}}{{if $project.Since}} since {{$project.Since}}{{end}}
 <details><summary>{{len $project.Changes}} commit{{if gt (len $project.Changes) 1}}s{{end}}</summary>
 <p>
 {{range $change := $project.Changes }}
-* {{$change.Commit}} {{$change.Description}}
+* {{$change.Commit}} {{inline $change.Description}}
+{{- end}}
+{{- if $project.BotCommits}}
+* {{$project.BotCommits}} dependency update commit{{if gt $project.BotCommits 1}}s{{end}}
 {{- end}}
 </p>
 </details>
+{{- if $project.Dependencies}}
+
+#### Dependency Changes ({{$project.Component}})
+{{range $dep := $project.Dependencies}}
+* **{{$dep.Name}}**	{{if $dep.Previous}}{{$dep.Previous}} -> {{$dep.Ref}}{{else}}{{$dep.Ref}} **_new_**{{end}}
+{{- end}}
+{{- end}}
 {{- end}}
 
 ### Dependency Changes
-{{if .Dependencies}}
-{{- range $dep := .Dependencies}}
+{{if .DependenciesByModule}}
+{{- range $i, $group := .DependenciesByModule}}
+{{- if gt (len $.DependenciesByModule) 1}}{{if $i}}
+{{end}}
+#### {{if $group.Module}}{{$group.Module}}{{else}}Other dependencies{{end}}
+{{end}}
+{{- range $dep := $group.Dependencies}}
+* **{{$dep.Name}}**	{{if $dep.Previous}}{{$dep.Previous}} -> {{$dep.Ref}}{{else}}{{$dep.Ref}} **_new_**{{end}}
+{{- end}}
+{{- end}}
+{{- else}}
+This release has no dependency changes
+{{- end}}
+
+{{- range $rollup := .Rollups}}
+
+### Changes since {{$rollup.Since}}
+
+All changes since the {{$rollup.Label}} {{$rollup.Since}}.
+{{- range $project := $rollup.Changes}}
+{{- if or $project.Changes $project.BotCommits}}
+
+<details><summary>{{len $project.Changes}} commit{{if gt (len $project.Changes) 1}}s{{end}}{{if $project.Component}} in {{$project.Component}}{{end}}{{if $project.Name}} from {{$project.Name}}{{end}}</summary>
+<p>
+{{range $change := $project.Changes }}
+* {{$change.Commit}} {{inline $change.Description}}
+{{- end}}
+{{- if $project.BotCommits}}
+* {{$project.BotCommits}} dependency update commit{{if gt $project.BotCommits 1}}s{{end}}
+{{- end}}
+</p>
+</details>
+{{- end}}
+{{- end}}
+{{- if $rollup.Contributors}}
+
+<details><summary>{{len $rollup.Contributors}} contributor{{if gt (len $rollup.Contributors) 1}}s{{end}}</summary>
+<p>
+{{range $contributor := $rollup.Contributors}}
+* {{$contributor}}
+{{- end}}
+</p>
+</details>
+{{- end}}
+{{- if $rollup.Dependencies}}
+
+<details><summary>{{len $rollup.Dependencies}} dependency change{{if gt (len $rollup.Dependencies) 1}}s{{end}}</summary>
+<p>
+{{range $dep := $rollup.Dependencies}}
 * **{{$dep.Name}}**	{{if $dep.Previous}}{{$dep.Previous}} -> {{$dep.Ref}}{{else}}{{$dep.Ref}} **_new_**{{end}}
 {{- end}}
+</p>
+</details>
+{{- end}}
+{{- end}}
+
+{{- if .Downloads}}
+
+### Downloads
+
+| File | Size | SHA256 |
+| ---- | ---- | ------ |
+{{- range $download := .Downloads}}
+| {{$download.Filename}} | {{$download.Size}} | {{$download.Hash}} |
+{{- end}}
+{{- end}}
+
+{{- if .Previous}}
+
+Previous release can be found at {{if .PreviousURL}}[{{.Previous}}]({{.PreviousURL}}){{else}}{{.Previous}}{{end}}
+{{- end}}
+`
+	htmlReleaseNotes = `<h2>{{if .ReleaseURL}}<a href="{{.ReleaseURL}}">{{.ProjectName}} {{.Version}}</a>{{else}}{{.ProjectName}} {{.Version}}{{end}} ({{.ReleaseDate}})</h2>
+
+<p>Welcome to the {{.Tag}} release of {{.ProjectName}}!
+{{- if .PreRelease }}<br>
+<em>This is a pre-release of {{.ProjectName}}</em>
+{{- end}}</p>
+
+{{markdown .Preface}}
+<p>Please try out the release binaries and report any issues
+{{- if .IssuesURL}} at
+<a href="{{.IssuesURL}}">{{.IssuesURL}}</a>{{end}}.</p>
+
+{{- range  $note := .OrderedNotes}}
+
+<h3>{{$note.Title}}</h3>
+
+{{markdown $note.Description}}
+{{- end}}
+
+{{- if .OrderedBreakingChanges}}
+
+<h3>Breaking Changes</h3>
+
+<ul>
+{{- range $change := .OrderedBreakingChanges}}
+<li>{{if $change.Project}}<strong>{{$change.Project}}</strong>: {{end}}{{inline $change.Description}}{{if $change.Commit}} ({{inline $change.Commit}}){{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+
+<h3>Contributors</h3>
+
+<ul>
+{{- range $contributor := .Contributors}}
+<li>{{$contributor}}</li>
+{{- end}}
+</ul>
+{{- if .NewContributors}}
+
+<h3>New Contributors</h3>
+
+<ul>
+{{- range $contributor := .NewContributors}}
+<li>{{$contributor}}{{with $contributor.Login}} (@{{.}}){{end}} made their first contribution in {{if $contributor.FirstCommit.Project}}{{$contributor.FirstCommit.Project}} {{end}}{{inline $contributor.FirstCommit.Commit}}</li>
+{{- end}}
+</ul>
+{{- end}}
+
+{{- range $project := .Changes}}
+
+<h3>Changes{{if $project.Component}} ({{$project.Component}}){{end}}{{if $project.Name}} from {{$project.Name}}{{end}}{{if $project.Since}} since {{$project.Since}}{{end}}</h3>
+
+<details><summary>{{len $project.Changes}} commit{{if gt (len $project.Changes) 1}}s{{end}}</summary>
+<ul>
+{{- range $change := $project.Changes }}
+<li>{{inline $change.Commit}} {{inline $change.Description}}</li>
+{{- end}}
+{{- if $project.BotCommits}}
+<li>{{$project.BotCommits}} dependency update commit{{if gt $project.BotCommits 1}}s{{end}}</li>
+{{- end}}
+</ul>
+</details>
+{{- if $project.Dependencies}}
+
+<h4>Dependency Changes ({{$project.Component}})</h4>
+
+<ul>
+{{- range $dep := $project.Dependencies}}
+<li><strong>{{$dep.Name}}</strong> {{if $dep.Previous}}{{$dep.Previous}} -&gt; {{$dep.Ref}}{{else}}{{$dep.Ref}} <strong><em>new</em></strong>{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}
+
+<h3>Dependency Changes</h3>
+{{if .DependenciesByModule}}
+{{- range $group := .DependenciesByModule}}
+{{- if gt (len $.DependenciesByModule) 1}}
+<h4>{{if $group.Module}}{{$group.Module}}{{else}}Other dependencies{{end}}</h4>
+{{- end}}
+<ul>
+{{- range $dep := $group.Dependencies}}
+<li><strong>{{$dep.Name}}</strong> {{if $dep.Previous}}{{$dep.Previous}} -&gt; {{$dep.Ref}}{{else}}{{$dep.Ref}} <strong><em>new</em></strong>{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- else}}
+<p>This release has no dependency changes</p>
+{{- end}}
+
+{{- range $rollup := .Rollups}}
+
+<h3>Changes since {{$rollup.Since}}</h3>
+
+<p>All changes since the {{$rollup.Label}} {{$rollup.Since}}.</p>
+{{- range $project := $rollup.Changes}}
+{{- if or $project.Changes $project.BotCommits}}
+
+<details><summary>{{len $project.Changes}} commit{{if gt (len $project.Changes) 1}}s{{end}}{{if $project.Component}} in {{$project.Component}}{{end}}{{if $project.Name}} from {{$project.Name}}{{end}}</summary>
+<ul>
+{{- range $change := $project.Changes }}
+<li>{{inline $change.Commit}} {{inline $change.Description}}</li>
+{{- end}}
+{{- if $project.BotCommits}}
+<li>{{$project.BotCommits}} dependency update commit{{if gt $project.BotCommits 1}}s{{end}}</li>
+{{- end}}
+</ul>
+</details>
+{{- end}}
+{{- end}}
+{{- if $rollup.Contributors}}
+
+<details><summary>{{len $rollup.Contributors}} contributor{{if gt (len $rollup.Contributors) 1}}s{{end}}</summary>
+<ul>
+{{- range $contributor := $rollup.Contributors}}
+<li>{{$contributor}}</li>
+{{- end}}
+</ul>
+</details>
+{{- end}}
+{{- if $rollup.Dependencies}}
+
+<details><summary>{{len $rollup.Dependencies}} dependency change{{if gt (len $rollup.Dependencies) 1}}s{{end}}</summary>
+<ul>
+{{- range $dep := $rollup.Dependencies}}
+<li><strong>{{$dep.Name}}</strong> {{if $dep.Previous}}{{$dep.Previous}} -&gt; {{$dep.Ref}}{{else}}{{$dep.Ref}} <strong><em>new</em></strong>{{end}}</li>
+{{- end}}
+</ul>
+</details>
+{{- end}}
+{{- end}}
+
+{{- if .Downloads}}
+
+<h3>Downloads</h3>
+
+<table>
+<thead><tr><th>File</th><th>Size</th><th>SHA256</th></tr></thead>
+<tbody>
+{{- range $download := .Downloads}}
+<tr><td>{{$download.Filename}}</td><td>{{$download.Size}}</td><td><code>{{$download.Hash}}</code></td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- end}}
+
+{{- if .Previous}}
+
+<p>Previous release can be found at {{if .PreviousURL}}<a href="{{.PreviousURL}}">{{.Previous}}</a>{{else}}{{.Previous}}{{end}}</p>
+{{- end}}
+`
+	asciidocReleaseNotes = `== {{if .ReleaseURL}}{{.ReleaseURL}}[{{.ProjectName}} {{.Version}}]{{else}}{{.ProjectName}} {{.Version}}{{end}} ({{.ReleaseDate}})
+
+Welcome to the {{.Tag}} release of {{.ProjectName}}!
+{{- if .PreRelease }} +
+_This is a pre-release of {{.ProjectName}}_
+{{- end}}
+
+{{markdown .Preface}}
+
+Please try out the release binaries and report any issues
+{{- if .IssuesURL}} at
+{{.IssuesURL}}{{end}}.
+
+{{- range  $note := .OrderedNotes}}
+
+=== {{inline $note.Title}}
+
+{{markdown $note.Description}}
+{{- end}}
+
+{{- if .OrderedBreakingChanges}}
+
+=== Breaking Changes
+{{range $change := .OrderedBreakingChanges}}
+* {{if $change.Project}}*{{$change.Project}}*: {{end}}{{inline $change.Description}}{{if $change.Commit}} ({{inline $change.Commit}}){{end}}
+{{- end}}
+{{- end}}
+
+=== Contributors
+{{range $contributor := .Contributors}}
+* {{inline (print $contributor)}}
+{{- end}}
+{{- if .NewContributors}}
+
+=== New Contributors
+{{range $contributor := .NewContributors}}
+* {{inline (print $contributor)}}{{with $contributor.Login}} (@{{.}}){{end}} made their first contribution in {{if $contributor.FirstCommit.Project}}{{$contributor.FirstCommit.Project}} {{end}}{{inline $contributor.FirstCommit.Commit}}
+{{- end}}
+{{- end -}}
+
+{{range $project := .Changes}}
+
+=== Changes{{if $project.Component}} ({{$project.Component}}){{end}}{{if $project.Name}} from {{$project.Name}}{{end}}{{if $project.Since}} since {{$project.Since}}{{end}}
+
+.{{len $project.Changes}} commit{{if gt (len $project.Changes) 1}}s{{end}}
+[%collapsible]
+====
+{{- range $change := $project.Changes }}
+* {{inline $change.Commit}} {{inline $change.Description}}
+{{- end}}
+{{- if $project.BotCommits}}
+* {{$project.BotCommits}} dependency update commit{{if gt $project.BotCommits 1}}s{{end}}
+{{- end}}
+====
+{{- if $project.Dependencies}}
+
+==== Dependency Changes ({{$project.Component}})
+{{range $dep := $project.Dependencies}}
+* *{{$dep.Name}}* {{if $dep.Previous}}{{$dep.Previous}} -> {{$dep.Ref}}{{else}}{{$dep.Ref}} *_new_*{{end}}
+{{- end}}
+{{- end}}
+{{- end}}
+
+=== Dependency Changes
+{{if .DependenciesByModule}}
+{{- range $i, $group := .DependenciesByModule}}
+{{- if gt (len $.DependenciesByModule) 1}}{{if $i}}
+{{end}}
+==== {{if $group.Module}}{{$group.Module}}{{else}}Other dependencies{{end}}
+{{end}}
+{{- range $dep := $group.Dependencies}}
+* *{{$dep.Name}}* {{if $dep.Previous}}{{$dep.Previous}} -> {{$dep.Ref}}{{else}}{{$dep.Ref}} *_new_*{{end}}
+{{- end}}
+{{- end}}
+{{- else}}
+This release has no dependency changes
+{{- end}}
+
+{{- range $rollup := .Rollups}}
+
+=== Changes since {{$rollup.Since}}
+
+All changes since the {{$rollup.Label}} {{$rollup.Since}}.
+{{- range $project := $rollup.Changes}}
+{{- if or $project.Changes $project.BotCommits}}
+
+.{{len $project.Changes}} commit{{if gt (len $project.Changes) 1}}s{{end}}{{if $project.Component}} in {{$project.Component}}{{end}}{{if $project.Name}} from {{$project.Name}}{{end}}
+[%collapsible]
+====
+{{- range $change := $project.Changes }}
+* {{inline $change.Commit}} {{inline $change.Description}}
+{{- end}}
+{{- if $project.BotCommits}}
+* {{$project.BotCommits}} dependency update commit{{if gt $project.BotCommits 1}}s{{end}}
+{{- end}}
+====
+{{- end}}
+{{- end}}
+{{- if $rollup.Contributors}}
+
+.{{len $rollup.Contributors}} contributor{{if gt (len $rollup.Contributors) 1}}s{{end}}
+[%collapsible]
+====
+{{- range $contributor := $rollup.Contributors}}
+* {{inline (print $contributor)}}
+{{- end}}
+====
+{{- end}}
+{{- if $rollup.Dependencies}}
+
+.{{len $rollup.Dependencies}} dependency change{{if gt (len $rollup.Dependencies) 1}}s{{end}}
+[%collapsible]
+====
+{{- range $dep := $rollup.Dependencies}}
+* *{{$dep.Name}}* {{if $dep.Previous}}{{$dep.Previous}} -> {{$dep.Ref}}{{else}}{{$dep.Ref}} *_new_*{{end}}
+{{- end}}
+====
+{{- end}}
+{{- end}}
+
+{{- if .Downloads}}
+
+=== Downloads
+
+|===
+|File |Size |SHA256
+{{range $download := .Downloads}}
+|{{inline $download.Filename}} |{{$download.Size}} |` + "`{{$download.Hash}}`" + `
+{{- end}}
+|===
+{{- end}}
+
+{{- if .Previous}}
+
+Previous release can be found at {{if .PreviousURL}}{{.PreviousURL}}[{{.Previous}}]{{else}}{{.Previous}}{{end}}
+{{- end}}
+`
+	textReleaseNotes = `{{underline "=" (printf "%s %s (%s)" .ProjectName .Version .ReleaseDate)}}
+
+Welcome to the {{.Tag}} release of {{.ProjectName}}!
+{{- if .PreRelease }}
+This is a pre-release of {{.ProjectName}}.
+{{- end}}
+
+{{markdown .Preface}}
+
+Please try out the release binaries and report any issues
+{{- if .IssuesURL}} at
+{{.IssuesURL}}{{end}}.
+
+{{- range  $note := .OrderedNotes}}
+
+{{underline "-" $note.Title}}
+
+{{markdown $note.Description}}
+{{- end}}
+
+{{- if .OrderedBreakingChanges}}
+
+{{underline "-" "Breaking Changes"}}
+{{range $change := .OrderedBreakingChanges}}
+* {{if $change.Project}}{{$change.Project}}: {{end}}{{inline $change.Description}}{{if $change.Commit}} ({{inline $change.Commit}}){{end}}
+{{- end}}
+{{- end}}
+
+{{underline "-" "Contributors"}}
+{{range $contributor := .Contributors}}
+* {{$contributor}}
+{{- end}}
+{{- if .NewContributors}}
+
+{{underline "-" "New Contributors"}}
+{{range $contributor := .NewContributors}}
+* {{$contributor}}{{with $contributor.Login}} (@{{.}}){{end}} made their first contribution in {{if $contributor.FirstCommit.Project}}{{$contributor.FirstCommit.Project}} {{end}}{{inline $contributor.FirstCommit.Commit}}
+{{- end}}
+{{- end -}}
+
+{{range $project := .Changes}}
+{{- $title := "Changes"}}
+{{- if $project.Component}}{{$title = print $title " (" $project.Component ")"}}{{end}}
+{{- if $project.Name}}{{$title = print $title " from " $project.Name}}{{end}}
+{{- if $project.Since}}{{$title = print $title " since " $project.Since}}{{end}}
+
+{{underline "-" $title}}
+{{range $change := $project.Changes }}
+* {{inline $change.Commit}} {{inline $change.Description}}
+{{- end}}
+{{- if $project.BotCommits}}
+* {{$project.BotCommits}} dependency update commit{{if gt $project.BotCommits 1}}s{{end}}
+{{- end}}
+{{- if $project.Dependencies}}
+
+{{underline "~" (print "Dependency Changes (" $project.Component ")")}}
+{{range $dep := $project.Dependencies}}
+* {{$dep.Name}}	{{if $dep.Previous}}{{$dep.Previous}} -> {{$dep.Ref}}{{else}}{{$dep.Ref}} (new){{end}}
+{{- end}}
+{{- end}}
+{{- end}}
+
+{{underline "-" "Dependency Changes"}}
+{{if .DependenciesByModule}}
+{{- range $i, $group := .DependenciesByModule}}
+{{- if gt (len $.DependenciesByModule) 1}}{{if $i}}
+{{end}}
+{{underline "~" (or $group.Module "Other dependencies")}}
+{{end}}
+{{- range $dep := $group.Dependencies}}
+* {{$dep.Name}}	{{if $dep.Previous}}{{$dep.Previous}} -> {{$dep.Ref}}{{else}}{{$dep.Ref}} (new){{end}}
+{{- end}}
+{{- end}}
 {{- else}}
 This release has no dependency changes
 {{- end}}
 
+{{- range $rollup := .Rollups}}
+
+{{underline "-" (print "Changes since " $rollup.Since)}}
+
+All changes since the {{$rollup.Label}} {{$rollup.Since}}.
+{{- range $project := $rollup.Changes}}
+{{- if or $project.Changes $project.BotCommits}}
+{{- $title := "Commits"}}
+{{- if $project.Component}}{{$title = print $title " in " $project.Component}}{{end}}
+{{- if $project.Name}}{{$title = print $title " from " $project.Name}}{{end}}
+
+{{underline "~" $title}}
+{{range $change := $project.Changes }}
+* {{inline $change.Commit}} {{inline $change.Description}}
+{{- end}}
+{{- if $project.BotCommits}}
+* {{$project.BotCommits}} dependency update commit{{if gt $project.BotCommits 1}}s{{end}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if $rollup.Contributors}}
+
+{{underline "~" "Contributors"}}
+{{range $contributor := $rollup.Contributors}}
+* {{$contributor}}
+{{- end}}
+{{- end}}
+{{- if $rollup.Dependencies}}
+
+{{underline "~" "Dependency Changes"}}
+{{range $dep := $rollup.Dependencies}}
+* {{$dep.Name}}	{{if $dep.Previous}}{{$dep.Previous}} -> {{$dep.Ref}}{{else}}{{$dep.Ref}} (new){{end}}
+{{- end}}
+{{- end}}
+{{- end}}
+
+{{- if .Downloads}}
+
+{{underline "-" "Downloads"}}
+{{range $download := .Downloads}}
+{{$download.Hash}}  {{$download.Filename}}	{{$download.Size}} bytes
+{{- end}}
+{{- end}}
+
 {{- if .Previous}}
 
-Previous release can be found at [{{.Previous}}](https://github.com/{{.GithubRepo}}/releases/tag/{{.Previous}})
+Previous release can be found at {{or .PreviousURL .Previous}}
 {{- end}}
 `
 )
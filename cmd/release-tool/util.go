@@ -35,6 +35,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
 	"golang.org/x/net/html"
 )
 
@@ -45,11 +46,18 @@ const (
 	makefile   = "Makefile"
 )
 
+// changelogFormat outputs the full hash, abbreviated hash, author name and
+// email, subject and body of each commit separated by unit separators,
+// records are terminated by a record separator.
+const changelogFormat = "%H%x1f%h%x1f%aN%x1f%aE%x1f%s%x1f%b%x1e"
+
 var errUnknownFormat = errors.New("unknown file format")
 
 func loadRelease(path string) (*release, error) {
 	var r release
-	if _, err := toml.DecodeFile(path, &r); err != nil {
+
+	md, err := toml.DecodeFile(path, &r)
+	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, errors.New("please specify the release file as the first argument")
 		}
@@ -57,25 +65,99 @@ func loadRelease(path string) (*release, error) {
 		return nil, err
 	}
 
+	r.meta = md
+
 	return &r, nil
 }
 
-func parseTag(path string) string {
-	return strings.TrimSuffix(filepath.Base(path), ".toml")
+// orderedKeys returns the keys of m in the order they are declared in the
+// TOML table, keys which are not declared in the file are sorted last.
+func orderedKeys[T any](md toml.MetaData, table string, m map[string]T) []string {
+	var (
+		keys = make([]string, 0, len(m))
+		seen = make(map[string]struct{}, len(m))
+	)
+
+	for _, key := range md.Keys() {
+		if len(key) < 2 || key[0] != table {
+			continue
+		}
+
+		if _, ok := m[key[1]]; !ok {
+			continue
+		}
+
+		if _, ok := seen[key[1]]; ok {
+			continue
+		}
+
+		seen[key[1]] = struct{}{}
+		keys = append(keys, key[1])
+	}
+
+	var rest []string
+
+	for key := range m {
+		if _, ok := seen[key]; !ok {
+			rest = append(rest, key)
+		}
+	}
+
+	sort.Strings(rest)
+
+	return append(keys, rest...)
 }
 
-func parseDependencies(commit string, makeDeps []makeDependency) ([]dependency, error) {
-	goDeps, err := parseGoDependencies(commit)
-	if err != nil {
-		return nil, err
+// orderedNotes returns the release notes in declaration order.
+func (r *release) orderedNotes() []note {
+	notes := make([]note, 0, len(r.Notes))
+
+	for _, key := range orderedKeys(r.meta, "notes", r.Notes) {
+		n := r.Notes[key]
+		n.Name = key
+		notes = append(notes, n)
 	}
 
-	mkDeps, err := parseMakeDependencies(commit, makeDeps)
-	if err != nil {
-		return nil, err
+	return notes
+}
+
+// orderedBreakingChanges returns the hand written breaking changes in declaration order.
+func (r *release) orderedBreakingChanges() []change {
+	changes := make([]change, 0, len(r.BreakingChanges))
+
+	for _, key := range orderedKeys(r.meta, "breaking", r.BreakingChanges) {
+		changes = append(changes, r.BreakingChanges[key])
+	}
+
+	return changes
+}
+
+// orderedRenameDeps returns the dependency renames in declaration order.
+func (r *release) orderedRenameDeps() []projectRename {
+	renames := make([]projectRename, 0, len(r.RenameDeps))
+
+	for _, key := range orderedKeys(r.meta, "rename_deps", r.RenameDeps) {
+		rename := r.RenameDeps[key]
+		rename.Name = key
+		renames = append(renames, rename)
+	}
+
+	return renames
+}
+
+// orderedMakeDeps returns the Makefile dependencies in declaration order.
+func (r *release) orderedMakeDeps() []makeDependency {
+	makeDeps := make([]makeDependency, 0, len(r.MakeDeps))
+
+	for _, key := range orderedKeys(r.meta, "make_deps", r.MakeDeps) {
+		makeDeps = append(makeDeps, r.MakeDeps[key])
 	}
 
-	return append(goDeps, mkDeps...), nil
+	return makeDeps
+}
+
+func parseTag(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), ".toml")
 }
 
 func parseMakeDependencies(commit string, makeDeps []makeDependency) ([]dependency, error) {
@@ -112,6 +194,10 @@ func parseMakeDependencies(commit string, makeDeps []makeDependency) ([]dependen
 		}
 
 		value := strings.TrimSpace(string(out))
+		if value == "" {
+			return nil, fmt.Errorf("variable %s is not defined in the Makefile", makeDep.Variable)
+		}
+
 		parts := strings.Split(value, ":")
 		value = parts[len(parts)-1]
 
@@ -207,9 +293,13 @@ func parseGoModDependencies(r io.Reader) ([]dependency, error) {
 		return nil, err
 	}
 
-	goMod, err := modfile.ParseLax("go.mod", contents, nil)
+	// replace directives are only parsed in strict mode, lax mode is kept
+	// for go.mod files with directives unknown to this version
+	goMod, err := modfile.Parse("go.mod", contents, nil)
 	if err != nil {
-		return nil, err
+		if goMod, err = modfile.ParseLax("go.mod", contents, nil); err != nil {
+			return nil, err
+		}
 	}
 
 	depMap := make(map[string]*dependency)
@@ -230,7 +320,10 @@ func parseGoModDependencies(r io.Reader) ([]dependency, error) {
 	}
 
 	for _, replace := range goMod.Replace {
-		if strings.HasPrefix(replace.New.Path, "./") {
+		if modfile.IsDirectoryPath(replace.New.Path) {
+			// modules replaced by a directory are part of the repository
+			delete(depMap, replace.Old.Path)
+
 			continue
 		}
 
@@ -408,13 +501,15 @@ func parseVendorConfDependencies(r io.Reader) ([]dependency, error) {
 	return deps, nil
 }
 
-func changelog(previous, commit string) ([]change, error) {
-	raw, err := getChangelog(previous, commit)
+// changelog returns the changes between previous and commit, limited to
+// the commits touching paths when set.
+func changelog(previous, commit string, parser *commitParser, paths []string) ([]change, error) {
+	raw, err := getChangelog(previous, commit, paths)
 	if err != nil {
 		return nil, err
 	}
 
-	return parseChangelog(raw)
+	return parseChangelog(raw, parser)
 }
 
 func gitChangeDiff(previous, commit string) string {
@@ -425,7 +520,7 @@ func gitChangeDiff(previous, commit string) string {
 	return commit
 }
 
-func getChangelog(previous, commit string) ([]byte, error) {
+func getChangelog(previous, commit string, paths []string) ([]byte, error) {
 	// add current directory as 'safe' to git, as otherwise git complains about different user owning the repo files
 	// when run via `docker run -v`
 	if cwd, err := os.Getwd(); err == nil {
@@ -434,7 +529,12 @@ func getChangelog(previous, commit string) ([]byte, error) {
 		}
 	}
 
-	return git("log", "--oneline", gitChangeDiff(previous, commit))
+	args := []string{"log", "--format=" + changelogFormat, gitChangeDiff(previous, commit)}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+
+	return git(args...)
 }
 
 func linkifyChanges(c []change, commit, msg func(change) (string, error), gfm bool) error {
@@ -461,47 +561,133 @@ func linkifyChanges(c []change, commit, msg func(change) (string, error), gfm bo
 	return nil
 }
 
-func parseChangelog(changelog []byte) ([]change, error) {
-	var (
-		changes []change
-		s       = bufio.NewScanner(bytes.NewReader(changelog))
-	)
+func parseChangelog(changelog []byte, parser *commitParser) ([]change, error) {
+	var changes []change
 
-	for s.Scan() {
-		fields := strings.Fields(s.Text())
+	for _, record := range strings.Split(string(changelog), "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
 
-		changes = append(changes, change{
-			Commit:      fields[0],
-			Description: strings.Join(fields[1:], " "),
-		})
-	}
+		fields := strings.SplitN(record, "\x1f", 6)
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("invalid changelog entry: %q", record)
+		}
 
-	if err := s.Err(); err != nil {
-		return nil, err
+		c := change{
+			Commit:      fields[1],
+			Description: strings.Join(strings.Fields(fields[4]), " "),
+			hash:        fields[0],
+			authorName:  fields[2],
+			authorEmail: fields[3],
+			body:        fields[5],
+		}
+
+		parser.parseConventional(&c)
+
+		changes = append(changes, c)
 	}
 
 	return changes, nil
 }
 
-func getPreviousTag(tag string) (string, error) {
-	dashFields := strings.FieldsFunc(tag, func(c rune) bool { return c == '-' })
-	pointFields := strings.FieldsFunc(dashFields[0], func(c rune) bool { return c == '.' })
-
-	o, err := git("tag", "-l", "--sort=creatordate", fmt.Sprintf("%s.%s.*", pointFields[0], pointFields[1]))
+// getPreviousTag returns the release preceding tag among the tags which are
+// ancestors of commit. With stable set, pre-releases are never returned.
+func getPreviousTag(tag, commit string, stable bool) (string, error) {
+	o, err := git("tag", "--merged", commit)
 	if err != nil {
 		return "", err
 	}
 
-	l := strings.Split(strings.TrimSpace(string(o)), "\n")
-	for i := len(l) - 1; i >= 0; i-- {
-		if l[i] == tag {
+	return previousSemverTag(tag, strings.Fields(string(o)), stable), nil
+}
+
+// previousSemverTag returns the highest tag which has a lower semver
+// precedence than tag, tags are only compared with tags of the same path
+// prefix such as `api/`. An empty string is returned if tag is not a
+// semantic version or no such tag exists.
+func previousSemverTag(tag string, tags []string, stable bool) string {
+	prefix, version, ok := splitSemverTag(tag)
+	if !ok {
+		logrus.Debugf("tag %s is not a semantic version", tag)
+
+		return ""
+	}
+
+	var previous, previousVersion string
+
+	for _, t := range tags {
+		p, v, ok := splitSemverTag(t)
+		if !ok || p != prefix || semver.Compare(v, version) >= 0 {
+			continue
+		}
+
+		if stable && semver.Prerelease(v) != "" {
 			continue
 		}
 
-		return l[i], nil
+		if previous == "" || semver.Compare(v, previousVersion) > 0 {
+			previous, previousVersion = t, v
+		}
 	}
 
-	return "", nil
+	return previous
+}
+
+// getPreviousPreRelease returns the pre-release of the same version as tag
+// preceding it among the tags which are ancestors of commit.
+func getPreviousPreRelease(tag, commit string) (string, error) {
+	o, err := git("tag", "--merged", commit)
+	if err != nil {
+		return "", err
+	}
+
+	return previousPreRelease(tag, strings.Fields(string(o))), nil
+}
+
+// previousPreRelease returns the highest pre-release of the same version as
+// tag which precedes it, such as `v1.5.0-rc.1` for `v1.5.0-rc.2` or
+// `v1.5.0`. Pre-releases of other versions are never returned.
+func previousPreRelease(tag string, tags []string) string {
+	_, version, ok := splitSemverTag(tag)
+	if !ok {
+		return ""
+	}
+
+	var candidates []string
+
+	for _, t := range tags {
+		if _, v, ok := splitSemverTag(t); ok && semver.Prerelease(v) != "" && releaseVersion(v) == releaseVersion(version) {
+			candidates = append(candidates, t)
+		}
+	}
+
+	return previousSemverTag(tag, candidates, false)
+}
+
+// releaseVersion returns the canonical semantic version without its
+// pre-release and build suffixes.
+func releaseVersion(version string) string {
+	v := semver.Canonical(version)
+
+	return strings.TrimSuffix(v, semver.Prerelease(v))
+}
+
+// splitSemverTag splits tag into its path prefix and semantic version, the
+// `v` prefix of the version is optional.
+func splitSemverTag(tag string) (string, string, bool) {
+	var prefix string
+
+	if idx := strings.LastIndex(tag, "/"); idx >= 0 {
+		prefix, tag = tag[:idx+1], tag[idx+1:]
+	}
+
+	if !strings.HasPrefix(tag, "v") {
+		tag = "v" + tag
+	}
+
+	return prefix, tag, semver.IsValid(tag)
 }
 
 func nextGitURLTry(url string) string {
@@ -627,28 +813,24 @@ func execMake(args ...string) ([]byte, error) {
 	return o, nil
 }
 
-func renameDependencies(deps []dependency, renames map[string]projectRename) {
+func renameDependencies(deps []dependency, renames []projectRename) {
 	if len(renames) == 0 {
 		return
 	}
 
-	type dep struct {
-		shortname string
-		name      string
-	}
+	// the first declared rename wins for duplicate old names
+	renameMap := map[string]projectRename{}
 
-	renameMap := map[string]dep{}
-	for shortname, rename := range renames {
-		renameMap[rename.Old] = dep{
-			shortname: shortname,
-			name:      rename.New,
+	for _, rename := range renames {
+		if _, ok := renameMap[rename.Old]; !ok {
+			renameMap[rename.Old] = rename
 		}
 	}
 
 	for i := range deps {
 		if updated, ok := renameMap[deps[i].Name]; ok {
-			logrus.Debugf("Renamed %s from %s to %s", updated.shortname, deps[i].Name, updated.name)
-			deps[i].Name = updated.name
+			logrus.Debugf("Renamed %s from %s to %s", updated.Name, deps[i].Name, updated.New)
+			deps[i].Name = updated.New
 		}
 	}
 }
@@ -739,13 +921,10 @@ func toDepMap(deps []dependency) map[string]dependency {
 	return out
 }
 
-type contributor struct {
-	name  string
-	email string
-}
-
-func addContributors(previous, commit string, contributors map[contributor]int) error {
-	raw, err := git("log", `--format=%aE %aN`, gitChangeDiff(previous, commit))
+// addContributors counts the commits between previous and commit of the
+// authors, project is the name of the project changes of the commits.
+func addContributors(previous, commit, project string, contributors *contributorSet) error {
+	raw, err := git("log", `--format=%H %aE %aN`, gitChangeDiff(previous, commit))
 	if err != nil {
 		return err
 	}
@@ -753,66 +932,60 @@ func addContributors(previous, commit string, contributors map[contributor]int)
 	s := bufio.NewScanner(bytes.NewReader(raw))
 
 	for s.Scan() {
-		p := strings.SplitN(s.Text(), " ", 2)
-		if len(p) != 2 {
+		p := strings.SplitN(s.Text(), " ", 3)
+		if len(p) != 3 {
 			return fmt.Errorf("invalid author line: %q", s.Text())
 		}
 
-		c := contributor{
-			name:  p[1],
-			email: p[0],
-		}
-
-		contributors[c]++
+		contributors.add(p[2], p[1], project, p[0])
 	}
 
 	return s.Err()
 }
 
-func orderContributors(contributors map[contributor]int) []string {
-	type contribstat struct {
-		name  string
-		email string
-		count int
+// addKnownContributors records the authors of the history of previous.
+func addKnownContributors(previous string, contributors *contributorSet) error {
+	if previous == "" {
+		return nil
 	}
 
-	all := make([]contribstat, 0, len(contributors))
-
-	for c, count := range contributors {
-		all = append(all, contribstat{
-			name:  c.name,
-			email: c.email,
-			count: count,
-		})
+	raw, err := git("log", `--format=%aE %aN`, previous)
+	if err != nil {
+		return err
 	}
 
-	sort.Slice(all, func(i, j int) bool {
-		if all[i].count == all[j].count {
-			return all[i].name < all[j].name
-		}
+	s := bufio.NewScanner(bytes.NewReader(raw))
 
-		return all[i].count > all[j].count
-	})
+	for s.Scan() {
+		p := strings.SplitN(s.Text(), " ", 2)
+		if len(p) != 2 {
+			return fmt.Errorf("invalid author line: %q", s.Text())
+		}
 
-	names := make([]string, len(all))
-	for i := range names {
-		logrus.Debugf("Contributor: %s <%s> with %d commits", all[i].name, all[i].email, all[i].count)
-		names[i] = all[i].name
+		contributors.addKnown(p[1], p[0])
 	}
 
-	return names
+	return s.Err()
 }
 
-// getTemplate will use a builtin template if the template is not specified on the cli.
+// getTemplate will use the builtin template of the output format if the template is not specified on the cli.
+// The default template file only replaces the markdown templates, other formats need an explicit --template.
 func getTemplate(context *cli.Context) (string, error) {
-	path := context.String("template")
+	var (
+		path   = context.String("template")
+		format = context.String("format")
+	)
+
+	if path == defaultTemplateFile && !context.IsSet("template") && format != formatMarkdown && format != formatGFM {
+		return renderers[format].template, nil
+	}
 
 	f, err := os.Open(path)
 	if err != nil {
 		// if the template file does not exist and the path is for the default template then
 		// return the compiled in template
 		if os.IsNotExist(err) && path == defaultTemplateFile {
-			return releaseNotes, nil
+			return renderers[format].template, nil
 		}
 
 		return "", err
@@ -828,42 +1001,6 @@ func getTemplate(context *cli.Context) (string, error) {
 	return string(data), nil
 }
 
-func githubCommitLink(repo string, gfm bool) func(change) (string, error) {
-	return func(c change) (string, error) {
-		if gfm {
-			return fmt.Sprintf("%s@%s", repo, c.Commit), nil
-		}
-
-		full, err := git("rev-parse", c.Commit)
-		if err != nil {
-			return "", err
-		}
-
-		commit := strings.TrimSpace(string(full))
-
-		return fmt.Sprintf("https://github.com/%s/commit/%s", repo, commit), nil
-	}
-}
-
-func githubPRLink(repo string) func(change) (string, error) {
-	r := regexp.MustCompile("^Merge pull request #[0-9]+")
-
-	return func(c change) (string, error) {
-		message := r.ReplaceAllStringFunc(c.Description, func(m string) string {
-			idx := strings.Index(m, "#")
-			pr := m[idx+1:]
-
-			// TODO: Validate links using github API
-			// TODO: Validate PR merged as commit hash
-			link := fmt.Sprintf("https://github.com/%s/pull/%s", repo, pr)
-
-			return fmt.Sprintf("%s [#%s](%s)", m[:idx], pr, link)
-		})
-
-		return message, nil
-	}
-}
-
 func resolveGitURL(name string, cache Cache) (string, error) {
 	u := "https://" + name + "?go-get=1"
 	if b, ok := cache.Get(u); ok {
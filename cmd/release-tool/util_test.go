@@ -16,7 +16,17 @@
 
 package main
 
-import "testing"
+import (
+	"flag"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v2"
+)
 
 func TestParseModuleCommit(t *testing.T) {
 	for i, tc := range []struct {
@@ -41,6 +51,53 @@ func TestParseModuleCommit(t *testing.T) {
 	}
 }
 
+func TestParseGoModDependencies(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		goMod string
+		deps  []string
+	}{
+		{
+			"require",
+			"module example.com/project\n\nrequire (\n\texample.com/a v1.0.0\n\texample.com/b v1.0.0 // indirect\n)\n",
+			[]string{"example.com/a@v1.0.0"},
+		},
+		{
+			"replaced by a version",
+			"module example.com/project\n\nrequire example.com/a v1.0.0\n\nreplace example.com/a => example.com/a v0.0.0-20210101000000-bbbbbbbbbbbb\n",
+			[]string{"example.com/a@bbbbbbbbbbbb"},
+		},
+		{
+			"replaced by a directory",
+			"module example.com/project\n\nrequire (\n\texample.com/a v1.0.0\n\texample.com/project/api v1.0.0\n)\n\nreplace example.com/project/api => ./api\n",
+			[]string{"example.com/a@v1.0.0"},
+		},
+		{
+			// directives unknown to strict parsing fall back to lax
+			// parsing, which ignores the replace directives
+			"unknown directive",
+			"module example.com/project\n\nunknown example.com/x\n\nrequire example.com/a v1.0.0\n\nreplace example.com/a => example.com/a v1.1.0\n",
+			[]string{"example.com/a@v1.0.0"},
+		},
+	} {
+		deps, err := parseGoModDependencies(strings.NewReader(tc.goMod))
+		if err != nil {
+			t.Fatalf("[%s] %v", tc.name, err)
+		}
+
+		actual := make([]string, 0, len(deps))
+		for _, d := range deps {
+			actual = append(actual, d.Name+"@"+d.Ref)
+		}
+
+		sort.Strings(actual)
+
+		if !reflect.DeepEqual(actual, tc.deps) {
+			t.Errorf("[%s] unexpected dependencies %v, expected %v", tc.name, actual, tc.deps)
+		}
+	}
+}
+
 func TestGetGitURL(t *testing.T) {
 	for _, tc := range []struct {
 		name string
@@ -60,3 +117,164 @@ func TestGetGitURL(t *testing.T) {
 		}
 	}
 }
+
+func TestParseChangelog(t *testing.T) {
+	raw := "1111111111111111111111111111111111111111\x1f1111111\x1fJane\x1fjane@example.com\x1ffeat(api)!: drop   v1\x1f\x1e\n" +
+		"2222222222222222222222222222222222222222\x1f2222222\x1fJohn\x1fjohn@example.com\x1ffix: handle nil\x1fSome details.\n\nBREAKING CHANGE: nil is\nnow an error\n\nSigned-off-by: A <a@example.com>\n\x1e\n" +
+		"3333333333333333333333333333333333333333\x1f3333333\x1fJane\x1fjane@example.com\x1fMerge pull request #1 from foo/bar\x1fBREAKING CHANGES: not a footer\n\x1e\n"
+
+	changes, err := parseChangelog([]byte(raw), defaultCommitParser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tc := range []struct {
+		commit      string
+		description string
+		breaking    bool
+		note        string
+	}{
+		{"1111111", "feat(api)!: drop v1", true, ""},
+		{"2222222", "fix: handle nil", true, "nil is now an error"},
+		{"3333333", "Merge pull request #1 from foo/bar", false, ""},
+	} {
+		c := changes[i]
+		if c.Commit != tc.commit || c.Description != tc.description || c.Breaking != tc.breaking || c.breakingNote != tc.note {
+			t.Errorf("[%d] unexpected change %+v", i, c)
+		}
+	}
+
+	if c := changes[1]; c.authorName != "John" || c.authorEmail != "john@example.com" {
+		t.Errorf("unexpected author %q <%s>", c.authorName, c.authorEmail)
+	}
+
+	r := &release{
+		BreakingChanges: map[string]change{
+			"manual": {Commit: "2222222", Description: "hand written"},
+		},
+	}
+
+	r.OrderedBreakingChanges = r.orderedBreakingChanges()
+	r.addBreakingChanges("", changes)
+	r.addBreakingChanges("", changes)
+
+	if len(r.OrderedBreakingChanges) != 2 ||
+		r.OrderedBreakingChanges[0].Description != "hand written" ||
+		r.OrderedBreakingChanges[1].Description != "feat(api)!: drop v1" {
+		t.Errorf("unexpected breaking changes %+v", r.OrderedBreakingChanges)
+	}
+}
+
+func TestOrderedNotes(t *testing.T) {
+	var r release
+
+	md, err := toml.Decode(`
+[notes.zeta]
+title = "First"
+
+[notes.alpha]
+title = "Second"
+
+[notes.mid]
+title = "Third"
+`, &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.meta = md
+	r.Notes["undeclared"] = note{Title: "Last"}
+
+	var titles []string
+	for _, n := range r.orderedNotes() {
+		titles = append(titles, n.Name+"="+n.Title)
+	}
+
+	if got := strings.Join(titles, ","); got != "zeta=First,alpha=Second,mid=Third,undeclared=Last" {
+		t.Errorf("unexpected note order %s", got)
+	}
+}
+
+func TestPreviousSemverTag(t *testing.T) {
+	tags := []string{
+		"v1.0.0", "v1.0.1", "v1.1.0-alpha.0", "v1.1.0-beta.0", "v1.1.0-rc.1", "v1.1.0-rc.0",
+		"v1.1.0", "v1.1.1", "v1.0.2", "v1.2.0-alpha.0", "api/v1.5.0", "api/v1.4.0", "latest",
+	}
+
+	for _, tc := range []struct {
+		tag      string
+		stable   bool
+		expected string
+	}{
+		{tag: "v1.0.3", expected: "v1.0.2"},
+		{tag: "v1.1.2", expected: "v1.1.1"},
+		{tag: "v1.1.0", expected: "v1.1.0-rc.1"},
+		{tag: "v1.1.0", stable: true, expected: "v1.0.2"},
+		{tag: "v1.1.0-rc.0", expected: "v1.1.0-beta.0"},
+		{tag: "v1.2.0", stable: true, expected: "v1.1.1"},
+		{tag: "v1.2.0-alpha.1", expected: "v1.2.0-alpha.0"},
+		{tag: "v1.2.0-alpha.1", stable: true, expected: "v1.1.1"},
+		{tag: "v2", expected: "v1.2.0-alpha.0"},
+		{tag: "1.0.1", expected: "v1.0.0"},
+		{tag: "api/v1.6.0", expected: "api/v1.5.0"},
+		{tag: "v1.0.0"},
+		{tag: "latest"},
+	} {
+		if previous := previousSemverTag(tc.tag, tags, tc.stable); previous != tc.expected {
+			t.Errorf("unexpected previous tag for %s (stable %t): %q, expected %q", tc.tag, tc.stable, previous, tc.expected)
+		}
+	}
+}
+
+func TestGetTemplate(t *testing.T) {
+	dir := t.TempDir()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { os.Chdir(cwd) }) //nolint: errcheck
+
+	const custom = "custom {{.Tag}}"
+
+	for _, name := range []string{defaultTemplateFile, "notes.tmpl"} {
+		if err = os.WriteFile(name, []byte(custom), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		args     []string
+		expected string
+	}{
+		{nil, custom},
+		{[]string{"--format", formatGFM}, custom},
+		// the default template file is markdown
+		{[]string{"--format", formatHTML}, htmlReleaseNotes},
+		{[]string{"--format", formatText}, textReleaseNotes},
+		{[]string{"--format", formatHTML, "--template", defaultTemplateFile}, custom},
+		{[]string{"--format", formatAsciiDoc, "--template", "notes.tmpl"}, custom},
+	} {
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		set.String("format", formatMarkdown, "")
+		set.String("template", defaultTemplateFile, "")
+
+		if err = set.Parse(tc.args); err != nil {
+			t.Fatal(err)
+		}
+
+		tmpl, err := getTemplate(cli.NewContext(cli.NewApp(), set, nil))
+		if err != nil {
+			t.Fatalf("%v: %v", tc.args, err)
+		}
+
+		if tmpl != tc.expected {
+			t.Errorf("%v: unexpected template %.40q", tc.args, tmpl)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v2"
+)
+
+var validateCommand = &cli.Command{
+	Name:      "validate",
+	Usage:     "check the release file against the repository",
+	ArgsUsage: "<release file>",
+	Action: func(context *cli.Context) error {
+		path := context.Args().First()
+		if path == "" {
+			return errors.New("please specify the release file as the first argument")
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		r, err := loadRelease(path)
+		if err != nil {
+			var perr toml.ParseError
+			if errors.As(err, &perr) {
+				return fmt.Errorf("%s:%d: %s", path, perr.Position.Line, perr.Message)
+			}
+
+			return err
+		}
+
+		problems := validateRelease(r, data)
+
+		var errCount int
+
+		for _, p := range problems {
+			if p.line > 0 {
+				fmt.Fprintf(context.App.Writer, "%s:%d: %s\n", path, p.line, p)
+			} else {
+				fmt.Fprintf(context.App.Writer, "%s: %s\n", path, p)
+			}
+
+			if !p.warning {
+				errCount++
+			}
+		}
+
+		if errCount > 0 {
+			return fmt.Errorf("%s has %d error(s) and %d warning(s)", path, errCount, len(problems)-errCount)
+		}
+
+		return nil
+	},
+}
+
+// problem is an issue found in a release file.
+type problem struct {
+	msg     string
+	line    int
+	warning bool
+}
+
+func (p problem) String() string {
+	if p.warning {
+		return "warning: " + p.msg
+	}
+
+	return "error: " + p.msg
+}
+
+// validateRelease checks every field of r against the repository and
+// returns all problems ordered by line.
+//
+//nolint:gocognit,gocyclo,cyclop
+func validateRelease(r *release, data []byte) []problem {
+	var (
+		problems []problem
+		lines    = keyLines(data)
+	)
+
+	report := func(warning bool, key string, format string, args ...any) {
+		problems = append(problems, problem{
+			msg:     fmt.Sprintf(format, args...),
+			line:    lines[key],
+			warning: warning,
+		})
+	}
+
+	for _, key := range r.meta.Undecoded() {
+		report(true, key.String(), "unknown key %q", key.String())
+	}
+
+	commit := r.Commit
+	if commit == "" {
+		report(false, "commit", "'commit' is not set")
+	} else if _, err := resolveCommit(commit); err != nil {
+		report(false, "commit", "commit %q does not exist", commit)
+
+		commit = ""
+	}
+
+	previous := r.Previous
+	if previous != "" {
+		if _, err := resolveCommit(previous); err != nil {
+			report(false, "previous", "previous release %q does not exist", previous)
+
+			previous = ""
+		} else {
+			if isTag, err := tagExists(previous); err == nil && !isTag {
+				report(true, "previous", "previous release %q is not a tag", previous)
+			}
+
+			if commit != "" {
+				if _, err = git("merge-base", "--is-ancestor", previous, commit); err != nil {
+					report(true, "previous", "previous release %q is not an ancestor of %q", previous, commit)
+				}
+			}
+		}
+	}
+
+	if r.Forge != "" {
+		if _, err := newForge(r.Forge, r.ForgeURL, r.GithubRepo); err != nil {
+			report(false, "forge", "%v", err)
+		}
+	}
+
+	if r.MatchDeps != "" {
+		if _, err := regexp.Compile(r.MatchDeps); err != nil {
+			report(false, "match_deps", "invalid 'match_deps' regexp: %v", err)
+		}
+	}
+
+	for _, name := range orderedKeys(r.meta, "trackers", r.Trackers) {
+		var (
+			t   = r.Trackers[name]
+			key = toml.Key{"trackers", name}.String()
+		)
+
+		if t.Pattern == "" || t.URL == "" {
+			report(false, key, "tracker %s must set 'pattern' and 'url'", name)
+
+			continue
+		}
+
+		if _, err := regexp.Compile(t.Pattern); err != nil {
+			report(false, key, "invalid pattern for tracker %s: %v", name, err)
+		}
+	}
+
+	if _, err := newBotFilter(r.Bots); err != nil {
+		report(false, "bots", "%v", err)
+	}
+
+	if _, err := newChangelogFilter(r.Changelog); err != nil {
+		report(false, "changelog", "%v", err)
+	}
+
+	for _, c := range r.orderedComponents() {
+		if len(c.Paths) == 0 {
+			report(false, toml.Key{"components", c.Name}.String(), "component %s has no paths", c.Name)
+		}
+	}
+
+	for _, name := range orderedKeys(r.meta, "make_deps", r.MakeDeps) {
+		var (
+			makeDep = r.MakeDeps[name]
+			key     = toml.Key{"make_deps", name}.String()
+		)
+
+		if makeDep.Repository == "" {
+			report(false, key, "'repository' is not set for Makefile variable %s", makeDep.Variable)
+		}
+
+		if commit == "" {
+			continue
+		}
+
+		if _, err := parseMakeDependencies(commit, []makeDependency{makeDep}); err != nil {
+			report(false, key, "%v", err)
+		}
+	}
+
+	if commit != "" && (len(r.IgnoreDeps) > 0 || len(r.RenameDeps) > 0) {
+		var (
+			known        = map[string]struct{}{}
+			previousDeps = map[string]struct{}{}
+		)
+
+		for _, makeDep := range r.MakeDeps {
+			known[makeDep.Repository] = struct{}{}
+		}
+
+		for _, rev := range []string{commit, previous} {
+			if rev == "" {
+				continue
+			}
+
+			modules, err := parseModules(rev)
+			if err != nil {
+				report(false, "commit", "unable to parse dependencies at %s: %v", rev, err)
+
+				continue
+			}
+
+			var deps []dependency
+			for _, m := range modules {
+				deps = append(deps, m.Deps...)
+			}
+
+			if rev == previous {
+				for _, dep := range deps {
+					previousDeps[dep.Name] = struct{}{}
+				}
+
+				renameDependencies(deps, r.orderedRenameDeps())
+			}
+
+			for _, dep := range deps {
+				known[dep.Name] = struct{}{}
+			}
+		}
+
+		for _, name := range r.IgnoreDeps {
+			if _, ok := known[name]; !ok {
+				report(true, "ignore_deps", "ignored dependency %s does not match any dependency", name)
+			}
+		}
+
+		if previous != "" {
+			for _, rename := range r.orderedRenameDeps() {
+				if _, ok := previousDeps[rename.Old]; !ok {
+					report(true, toml.Key{"rename_deps", rename.Name}.String(), "renamed dependency %s is not a dependency of %s", rename.Old, previous)
+				}
+			}
+		}
+	}
+
+	if r.ChglogConfig != "" && commit != "" {
+		cfg, err := loadChglogConfig(commit, r.ChglogConfig)
+		if err == nil {
+			_, err = cfg.parser()
+		}
+
+		if err != nil {
+			report(false, "chglog_config", "%v", err)
+		}
+	}
+
+	for i, group := range r.ChangeGroups {
+		if group.Title == "" {
+			report(false, "change_groups", "change group %d has no title", i+1)
+		}
+
+		if len(group.Types) == 0 {
+			report(true, "change_groups", "change group %q has no types", group.Title)
+		}
+	}
+
+	if r.Artifacts != "" {
+		if fi, err := os.Stat(r.Artifacts); err != nil || !fi.IsDir() {
+			report(false, "artifacts", "artifacts directory %s does not exist", r.Artifacts)
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].line < problems[j].line
+	})
+
+	return problems
+}
+
+// keyLines locates the line of every key and table defined in a TOML
+// document, keys are formatted as toml.Key strings. The first definition of
+// a key wins, for arrays of tables this is the first table.
+func keyLines(data []byte) map[string]int {
+	var (
+		lines     = map[string]int{}
+		table     []string
+		multiline string
+	)
+
+	for i, line := range strings.Split(string(data), "\n") {
+		ln := strings.TrimSpace(line)
+
+		if multiline != "" {
+			if strings.Contains(ln, multiline) {
+				multiline = ""
+			}
+
+			continue
+		}
+
+		if ln == "" || ln[0] == '#' {
+			continue
+		}
+
+		if ln[0] == '[' {
+			header := strings.Trim(strings.SplitN(ln, "]", 2)[0], "[] \t")
+			if strings.HasPrefix(ln, "[[") {
+				header = strings.Trim(strings.SplitN(ln, "]]", 2)[0], "[] \t")
+			}
+
+			table = splitKey(header)
+			addKeyLine(lines, table, i+1)
+
+			continue
+		}
+
+		idx := strings.Index(ln, "=")
+		if idx < 0 {
+			continue
+		}
+
+		key := append(append([]string{}, table...), splitKey(ln[:idx])...)
+		addKeyLine(lines, key, i+1)
+
+		value := strings.TrimSpace(ln[idx+1:])
+
+		for _, delim := range []string{`"""`, `'''`} {
+			if strings.HasPrefix(value, delim) && !strings.Contains(value[3:], delim) {
+				multiline = delim
+			}
+		}
+	}
+
+	return lines
+}
+
+func addKeyLine(lines map[string]int, key []string, line int) {
+	k := toml.Key(key).String()
+	if _, ok := lines[k]; !ok {
+		lines[k] = line
+	}
+}
+
+// splitKey splits a dotted TOML key into its unquoted parts.
+func splitKey(key string) []string {
+	var (
+		parts []string
+		cur   strings.Builder
+		quote rune
+	)
+
+	for _, c := range key {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '.':
+			parts = append(parts, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		case c == ' ' || c == '\t':
+		default:
+			cur.WriteRune(c)
+		}
+	}
+
+	return append(parts, strings.TrimSpace(cur.String()))
+}
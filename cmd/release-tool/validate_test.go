@@ -0,0 +1,70 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateRelease(t *testing.T) {
+	testRepo(t)
+	testCommit(t, "go.mod", "module example.com/test\n\ngo 1.22\n\nrequire github.com/containerd/log v0.1.0\n", "initial commit")
+
+	const releaseFile = `commit = "HEAD"
+project_name = "test"
+previous = "v0.0.9"
+match_deps = "^github.com/(containerd/[a-z+)$"
+ignore_deps = ["github.com/containerd/log", "github.com/missing/dep"]
+
+[notes.first]
+title = "First"
+descripton = """
+typo
+"""
+`
+
+	path := filepath.Join(t.TempDir(), "v0.1.0.toml")
+	if err := os.WriteFile(path, []byte(releaseFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := loadRelease(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problems := validateRelease(r, []byte(releaseFile))
+
+	expected := []problem{
+		{line: 3, msg: `previous release "v0.0.9" does not exist`},
+		{line: 4, msg: "invalid 'match_deps' regexp: error parsing regexp: missing closing ]: `[a-z+)$`"},
+		{line: 5, msg: "ignored dependency github.com/missing/dep does not match any dependency", warning: true},
+		{line: 9, msg: `unknown key "notes.first.descripton"`, warning: true},
+	}
+
+	if len(problems) != len(expected) {
+		t.Fatalf("unexpected problems %+v", problems)
+	}
+
+	for i := range expected {
+		if problems[i] != expected[i] {
+			t.Errorf("[%d] unexpected problem %+v, expected %+v", i, problems[i], expected[i])
+		}
+	}
+}